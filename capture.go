@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"time"
+	"voronoi/geom"
+	"voronoi/glu"
+)
+
+// Layout of the timestamps in the names of screenshots and recordings
+const captureTimeLayout = "2006-01-02-150405.000"
+
+// Screenshots, SVG exports and frame sequences of the window. S takes a screenshot, E exports
+// the diagram, V starts and stops a recording, and -record records from the first frame on.
+type capture struct {
+	// Take a screenshot at the end of the frame
+	screenshot bool
+	// Export the diagram of the frame as SVG
+	svg bool
+
+	// Directory the frames of the recording go to, empty when not recording
+	recordDir string
+	// Number of frames recorded so far
+	recordFrames int
+	// Globals.Time when the recording started. The recorded frames are timed as if they came
+	// exactly 1/fps seconds apart, however long they take to render and save.
+	recordStart float32
+	// Frame rate of the recordings, defaultRecordFPS when not set
+	fps float64
+
+	// Added to the real time, so that the time goes on from where the last recording left it
+	// instead of jumping back to the real time
+	timeOffset float32
+	// Globals.Time of the end of the recording just stopped, until the next frame sets
+	// timeOffset from it
+	stoppedAt float32
+	stopped   bool
+}
+
+// Frame rate of the recordings when none is set
+const defaultRecordFPS = 60
+
+var windowCapture = capture{}
+
+// Start recording into dir, which is created if needed.
+func (c *capture) startRecording(dir string, start float32) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	c.recordDir = dir
+	c.recordFrames = 0
+	c.recordStart = start
+	fmt.Printf("Recording into %s\n", dir)
+	return nil
+}
+
+func (c *capture) stopRecording() {
+	fmt.Printf("Recorded %d frames into %s\n", c.recordFrames, c.recordDir)
+	c.stoppedAt = c.recordTime()
+	c.stopped = true
+	c.recordDir = ""
+}
+
+// Start a recording into a new timestamped directory, or stop the current one.
+func (c *capture) toggleRecording(now float32) error {
+	if c.recordDir != "" {
+		c.stopRecording()
+		return nil
+	}
+	return c.startRecording("voronoi-"+time.Now().Format(captureTimeLayout), c.frameTime(now))
+}
+
+// The time of the frame about to be drawn: real is the time since the start, which recordings
+// replace with their simulated time. Once a recording stops, the time goes on from the
+// simulated time of its end.
+func (c *capture) frameTime(real float32) float32 {
+	if c.recordDir != "" {
+		return c.recordTime()
+	}
+	if c.stopped {
+		c.timeOffset = c.stoppedAt - real
+		c.stopped = false
+	}
+	return real + c.timeOffset
+}
+
+// The simulated time of the next frame of the recording
+func (c *capture) recordTime() float32 {
+	fps := c.fps
+	if fps <= 0 {
+		fps = defaultRecordFPS
+	}
+	return c.recordStart + float32(float64(c.recordFrames)/fps)
+}
+
+// Save the frame just drawn into the back buffer, as a screenshot if one was asked for and as
+// the next frame of the recording. Call it before swapping the buffers.
+func (c *capture) frameDone(width, height int32) error {
+	if !c.screenshot && c.recordDir == "" {
+		return nil
+	}
+	img := glu.ReadPixels(0, 0, width, height)
+	opaque(img)
+
+	if c.screenshot {
+		c.screenshot = false
+		path := "voronoi-" + time.Now().Format(captureTimeLayout) + ".png"
+		if err := glu.SavePNG(path, img); err != nil {
+			return err
+		}
+		fmt.Printf("Saved %s\n", path)
+	}
+	if c.recordDir != "" {
+		path := filepath.Join(c.recordDir, fmt.Sprintf("frame-%05d.png", c.recordFrames))
+		if err := glu.SavePNG(path, img); err != nil {
+			c.stopRecording()
+			return err
+		}
+		c.recordFrames++
+	}
+	return nil
+}
+
+// Export the diagram of the frame to an SVG file, if it was asked for. bounds is the part of the
+// diagram on screen, and width and height the size of the window.
+func (c *capture) saveSVG(seeds []Seed, bounds geom.Rect, width, height int) error {
+	if !c.svg {
+		return nil
+	}
+	c.svg = false
+	path := "voronoi-" + time.Now().Format(captureTimeLayout) + ".svg"
+	if err := exportSVG(path, seeds, state.metric, state.p, state.weighting, bounds, width, height, svgFlagOptions()); err != nil {
+		return err
+	}
+	fmt.Printf("Saved %s\n", path)
+	return nil
+}
+
+// The window is opaque, whatever blending left in the alpha channel of the back buffer.
+func opaque(img *image.RGBA) {
+	for i := 3; i < len(img.Pix); i += 4 {
+		img.Pix[i] = 255
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func closeTime(got, want float32) bool {
+	return math.Abs(float64(got-want)) < 1e-5
+}
+
+// Recordings time their frames 1/fps seconds apart, and once stopped the time goes on from there.
+func TestCaptureFrameTime(t *testing.T) {
+	c := capture{fps: 10}
+	if got := c.frameTime(1); got != 1 {
+		t.Errorf("before recording: %v, want the real time 1", got)
+	}
+	// As toggleRecording does, without a directory in the working directory
+	if err := c.startRecording(t.TempDir(), c.frameTime(2)); err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []float32{2, 2.1, 2.2} {
+		if got := c.frameTime(5); !closeTime(got, want) {
+			t.Errorf("recorded frame %d: %v, want %v", i, got, want)
+		}
+		c.recordFrames++
+	}
+	c.stopRecording()
+	for _, test := range []struct{ real, want float32 }{{5, 2.3}, {6, 3.3}} {
+		if got := c.frameTime(test.real); !closeTime(got, test.want) {
+			t.Errorf("after recording, at %v: %v, want %v", test.real, got, test.want)
+		}
+	}
+
+	// A new recording starts from the time on screen
+	if err := c.startRecording(t.TempDir(), c.frameTime(7)); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.frameTime(8); !closeTime(got, 4.3) {
+		t.Errorf("second recording: %v, want 4.3", got)
+	}
+}
+
+// Without a frame rate, recordings use the default one.
+func TestCaptureDefaultFPS(t *testing.T) {
+	c := capture{}
+	if err := c.startRecording(t.TempDir(), 1); err != nil {
+		t.Fatal(err)
+	}
+	c.recordFrames = defaultRecordFPS
+	if got := c.frameTime(0); !closeTime(got, 2) {
+		t.Errorf("after a second of frames: %v, want 2", got)
+	}
+}
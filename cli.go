@@ -0,0 +1,208 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"math/rand"
+	"strings"
+	"voronoi/geom"
+	"voronoi/glu"
+)
+
+// The command line. Every flag has a default, so that the program runs without any, and -help
+// prints them all after the overview in usage.
+
+// The window
+var (
+	widthFlag      = flag.Int("width", windowWidth, "width of the window, and of the -png and -svg images")
+	heightFlag     = flag.Int("height", windowHeight, "height of the window, and of the -png and -svg images")
+	fullscreenFlag = flag.Bool("fullscreen", false, "open the window full screen on the primary monitor, at its resolution")
+	vsyncFlag      = flag.Bool("vsync", true, "wait for the vertical blank to swap frames, capping the frame rate at the refresh rate of the monitor")
+	titleFlag      = flag.String("title", "goronoi", "title of the window")
+	fontSizeFlag   = flag.Int("font-size", 12, "size of the text over the diagram, in points")
+)
+
+var rendererFlag = flag.String("renderer", rendererDirect, "Voronoi renderer: "+rendererDirect+" or "+rendererJFA)
+
+// Load the shaders of the direct renderer from disk and reload them when they change
+var shadersFlag = flag.String("shaders", "", "load quad.vert, quad.frag and seeds.glsl from this directory, and reload them when they change")
+
+// See glu.DebugUniforms
+var debugUniformsFlag = flag.Bool("debug-uniforms", false, "check the value of every uniform after setting it, and fail on uniforms the shaders do not use")
+
+// Random seeds, see randomSeeds
+var (
+	countFlag    = flag.Int("count", 0, "start with this many random seeds instead of the default ones, and have R generate as many")
+	randSeedFlag = flag.Int64("rand-seed", 0, "seed of the random number generator behind -count and R, to get the same seeds every run (from the clock without it)")
+)
+
+// How the diagram is drawn. Without these, the scene or the defaults decide.
+var (
+	metricFlag    = flag.String("metric", "", "distance metric: euclidean (L2), manhattan (L1), chebyshev (L∞) or minkowski (Lp)")
+	pFlag         = flag.Float64("p", geom.DefaultMinkowskiP, "p of the minkowski metric, at least 1")
+	weightingFlag = flag.String("weighting", "", "how the weights of the seeds change the distances: unweighted, power or additive")
+	paletteFlag   = flag.String("palette", "", "colors of the seeds, in order, as comma separated hex like #1173b9,#d75526")
+)
+
+// Headless rendering, see headless.go
+var pngFlag = flag.String("png", "", "render the diagram into this PNG file without opening a window, and exit")
+
+// Frame sequences, see capture.go
+var (
+	recordFlag    = flag.String("record", "", "record every frame into this directory as a numbered PNG sequence")
+	recordFPSFlag = flag.Float64("record-fps", defaultRecordFPS, "frame rate of the recordings: the frames are timed 1/fps seconds apart")
+)
+
+// Seeds from GeoJSON, WKT, CSV or TSV files and their cells, see geo.go and csv.go
+var (
+	seedsFlag   = flag.String("seeds", "", "load the seeds from this GeoJSON (.geojson, .json), WKT (.wkt), CSV (.csv) or TSV (.tsv) file of points")
+	columnsFlag = flag.String("columns", "", "columns of the -seeds CSV or TSV file, e.g. x=lon,y=lat,color=colour,weight=w,label=name, by header name or number from 1")
+	cellsFlag   = flag.String("cells", "", "write the cells of the seeds into this GeoJSON (.geojson, .json) or WKT (.wkt) file without opening a window, and exit")
+)
+
+// Scene files, see scene.go
+var sceneFlag = flag.String("scene", "", "scene file (.json or .bin) to load at startup if it exists, and to save with Ctrl+S and load with Ctrl+O, which use "+defaultScenePath+" without it")
+
+// Where Ctrl+S and Ctrl+O save and load the scene without -scene
+const defaultScenePath = "scene.json"
+
+func scenePath() string {
+	if *sceneFlag != "" {
+		return *sceneFlag
+	}
+	return defaultScenePath
+}
+
+// Number of seed edits which can be undone
+var historyFlag = flag.Int("history", defaultHistoryDepth, "number of edits of the seeds which can be undone")
+
+// SVG export, see svg.go
+var (
+	svgFlag         = flag.String("svg", "", "export the diagram into this SVG file without opening a window, and exit")
+	svgSeedsFlag    = flag.Bool("svg-seeds", false, "draw the seeds in SVG exports")
+	svgDelaunayFlag = flag.Bool("svg-delaunay", false, "draw the Delaunay triangulation in SVG exports")
+	svgLabelsFlag   = flag.Bool("svg-labels", false, "label the seeds with their index in SVG exports")
+)
+
+func svgFlagOptions() svgOptions {
+	return svgOptions{seeds: *svgSeedsFlag, delaunay: *svgDelaunayFlag, labels: *svgLabelsFlag}
+}
+
+// Printed by -help, before the flags
+const usageText = `Usage: %s [flags]
+
+Draws the Voronoi diagram of a set of seeds in a window. The seeds are the default four, random
+ones (-count), the seeds of a file (-seeds) or those of a scene (-scene). Flags given on the
+command line win over the scene.
+
+With -png, -svg or -cells, the diagram is written into those files instead, without opening a
+window, and the program exits.
+
+Keys:
+  Tab             edit the seeds with the mouse: click to add, drag to move, right-click to delete, C to recolor
+  L               animate Lloyd relaxation
+  M, W            next metric, next weighting
+  + -             change the p of the minkowski metric
+  R               random seeds
+  S, E, V         screenshot, SVG export, start or stop recording the frames
+  Ctrl+S, Ctrl+O  save, load the scene
+  Ctrl+Z          undo, with Shift redo
+  Esc             quit
+
+Flags:
+`
+
+func usage() {
+	fmt.Fprintf(flag.CommandLine.Output(), usageText, flag.CommandLine.Name())
+	flag.PrintDefaults()
+}
+
+// Parse the command line and check the flags which do not depend on anything else.
+func parseFlags() error {
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments %q, see -help", flag.Args())
+	}
+	switch {
+	case *rendererFlag != rendererDirect && *rendererFlag != rendererJFA:
+		return fmt.Errorf("unknown renderer %q", *rendererFlag)
+	case *widthFlag <= 0 || *heightFlag <= 0:
+		return fmt.Errorf("-width and -height must be positive, not %dx%d", *widthFlag, *heightFlag)
+	case *fontSizeFlag <= 0:
+		return fmt.Errorf("-font-size must be positive, not %d", *fontSizeFlag)
+	case *recordFPSFlag <= 0:
+		return fmt.Errorf("-record-fps must be positive, not %v", *recordFPSFlag)
+	case *countFlag < 0:
+		return fmt.Errorf("-count must not be negative, not %d", *countFlag)
+	case *countFlag > 0 && *seedsFlag != "":
+		return errors.New("-count and -seeds both give the seeds, use one of them")
+	case *pFlag < 1:
+		return fmt.Errorf("-p must be at least 1, not %v", *pFlag)
+	}
+	return nil
+}
+
+// Apply the flags given on the command line to the state, over what a scene may have set.
+// Flags left out keep the state as it is.
+func applyFlags() error {
+	given := map[string]bool{}
+	flag.Visit(func(f *flag.Flag) {
+		given[f.Name] = true
+	})
+
+	if given["width"] {
+		state.windowWidth = *widthFlag
+	}
+	if given["height"] {
+		state.windowHeight = *heightFlag
+	}
+	if *metricFlag != "" {
+		metric, err := geom.ParseMetric(*metricFlag)
+		if err != nil {
+			return err
+		}
+		state.metric = metric
+	}
+	if given["p"] {
+		state.p = *pFlag
+	}
+	if *weightingFlag != "" {
+		weighting, err := geom.ParseWeighting(*weightingFlag)
+		if err != nil {
+			return err
+		}
+		state.weighting = weighting
+	}
+	if *paletteFlag != "" {
+		colors, err := parsePalette(*paletteFlag)
+		if err != nil {
+			return err
+		}
+		// The seeds there are so far take the new colors, those added later follow
+		palette = colors
+		for i := range state.seeds {
+			state.seeds[i].Color = paletteColor(i)
+		}
+	}
+	if given["rand-seed"] {
+		seedRand = rand.New(rand.NewSource(*randSeedFlag))
+	}
+	glu.DebugUniforms = *debugUniformsFlag
+	windowCapture.fps = *recordFPSFlag
+	return nil
+}
+
+// Parse a comma separated list of hex colors, see parseHexColor.
+func parsePalette(list string) ([][3]float32, error) {
+	var colors [][3]float32
+	for _, item := range strings.Split(list, ",") {
+		color, err := parseHexColor(strings.TrimSpace(item))
+		if err != nil {
+			return nil, fmt.Errorf("palette: %w", err)
+		}
+		colors = append(colors, color)
+	}
+	return colors, nil
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"voronoi/geom"
+)
+
+// The fields of a seed which can be read from a column of a CSV or TSV file. x and y are
+// required, the others optional.
+var csvFields = []string{"x", "y", "color", "weight", "label"}
+
+// Read seeds from a CSV file, or a TSV file with comma '\t', in file coordinates. Every row is a
+// seed. columns maps the fields of the seeds to columns, e.g. "x=lon,y=lat,label=name", either
+// by the name in the header row or by number, counting from 1. Fields left out are taken from
+// the header columns of the same name, or for x and y from the first two columns if there is no
+// header. The first row is a header if any column is mapped by name, or if its x is not a
+// number.
+//
+// Colors are hex, #1173b9 or 1173b9, and seeds without one are colored from the palette.
+// Weights are in the units of x and y. Labels go into the properties of the seeds.
+func readCSVSeeds(r io.Reader, comma rune, columns string) ([]Seed, error) {
+	mapping, err := parseCSVColumns(columns)
+	if err != nil {
+		return nil, err
+	}
+
+	var reader csvRecordReader
+	if comma == '\t' {
+		reader = &tsvReader{scanner: bufio.NewScanner(r)}
+	} else {
+		csvReader := csv.NewReader(r)
+		csvReader.Comma = comma
+		csvReader.Comment = '#'
+		csvReader.FieldsPerRecord = -1
+		csvReader.TrimLeadingSpace = true
+		reader = csvReader
+	}
+
+	first, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	line, _ := reader.FieldPos(0)
+
+	header := mapping.byName()
+	if !header {
+		x := 0
+		if column, ok := mapping["x"]; ok {
+			x, _ = strconv.Atoi(column)
+			x--
+		}
+		if x < len(first) {
+			_, err := strconv.ParseFloat(strings.TrimSpace(first[x]), 64)
+			header = err != nil
+		}
+	}
+
+	var indices map[string]int
+	if header {
+		indices, err = mapping.resolve(first)
+	} else {
+		indices, err = mapping.resolve(nil)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("line %d: %w", line, err)
+	}
+
+	var seeds []Seed
+	record := first
+	if header {
+		record, err = reader.Read()
+	}
+	for ; err == nil; record, err = reader.Read() {
+		line, _ := reader.FieldPos(0)
+		seed, err := parseCSVSeed(record, indices, len(seeds))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		seeds = append(seeds, seed)
+	}
+	if !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	return seeds, nil
+}
+
+// The records of a CSV or TSV file, and the line of the last one. A csv.Reader, or a tsvReader.
+type csvRecordReader interface {
+	Read() ([]string, error)
+	FieldPos(field int) (line, column int)
+}
+
+// Reads TSV, which has no quoting: every line is a record of fields separated by tabs, unlike
+// with csv.Reader, which treats fields starting with a quote as quoted even with LazyQuotes.
+// Blank lines and lines starting with # are skipped, as with csv.Reader.
+type tsvReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+func (r *tsvReader) Read() ([]string, error) {
+	for r.scanner.Scan() {
+		r.line++
+		text := strings.TrimSuffix(r.scanner.Text(), "\r")
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		return strings.Split(text, "\t"), nil
+	}
+	if err := r.scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+func (r *tsvReader) FieldPos(int) (line, column int) {
+	return r.line, 1
+}
+
+// Column of every field of the seeds, by name or number as given on the command line
+type csvColumns map[string]string
+
+func parseCSVColumns(columns string) (csvColumns, error) {
+	mapping := csvColumns{}
+	if strings.TrimSpace(columns) == "" {
+		return mapping, nil
+	}
+	for _, item := range strings.Split(columns, ",") {
+		field, column, ok := strings.Cut(item, "=")
+		field, column = strings.ToLower(strings.TrimSpace(field)), strings.TrimSpace(column)
+		if !ok || column == "" {
+			return nil, fmt.Errorf("column mapping %q is not field=column", item)
+		}
+		known := false
+		for _, name := range csvFields {
+			known = known || field == name
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown field %q in the column mapping, expected one of %s", field, strings.Join(csvFields, ", "))
+		}
+		if number, err := strconv.Atoi(column); err == nil && number < 1 {
+			return nil, fmt.Errorf("column %d of %s, columns count from 1", number, field)
+		}
+		mapping[field] = column
+	}
+	return mapping, nil
+}
+
+// Whether any field is mapped to a column by name
+func (mapping csvColumns) byName() bool {
+	for _, column := range mapping {
+		if _, err := strconv.Atoi(column); err != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Find the index of the column of every field, in the header if there is one. Optional fields
+// without a column are left out.
+func (mapping csvColumns) resolve(header []string) (map[string]int, error) {
+	indices := map[string]int{}
+	for _, field := range csvFields {
+		column, mapped := mapping[field]
+		if number, err := strconv.Atoi(column); mapped && err == nil {
+			indices[field] = number - 1
+			continue
+		}
+		if !mapped {
+			column = field
+		}
+		found := false
+		for i, name := range header {
+			if strings.EqualFold(strings.TrimSpace(name), column) {
+				indices[field], found = i, true
+				break
+			}
+		}
+		switch {
+		case found:
+		case mapped:
+			return nil, fmt.Errorf("no column %q in the header", column)
+		case header == nil && field == "x":
+			indices[field] = 0
+		case header == nil && field == "y":
+			indices[field] = 1
+		case field == "x" || field == "y":
+			return nil, fmt.Errorf("no %s column in the header, map it with -columns", field)
+		}
+	}
+	return indices, nil
+}
+
+// Parse the seed of one row. i is its index, for the palette color.
+func parseCSVSeed(record []string, indices map[string]int, i int) (Seed, error) {
+	field := func(name string) (string, bool) {
+		index, ok := indices[name]
+		if !ok || index >= len(record) {
+			return "", false
+		}
+		value := strings.TrimSpace(record[index])
+		return value, value != ""
+	}
+	number := func(name string) (float64, error) {
+		value, ok := field(name)
+		if !ok {
+			return 0, fmt.Errorf("no %s in column %d", name, indices[name]+1)
+		}
+		x, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return 0, fmt.Errorf("%s %q in column %d is not a number", name, value, indices[name]+1)
+		}
+		return x, nil
+	}
+
+	seed := Seed{Color: paletteColor(i)}
+	x, err := number("x")
+	if err != nil {
+		return Seed{}, err
+	}
+	y, err := number("y")
+	if err != nil {
+		return Seed{}, err
+	}
+	seed.Position = geom.Pt(x, y)
+
+	if _, ok := field("weight"); ok {
+		if seed.Weight, err = number("weight"); err != nil {
+			return Seed{}, err
+		}
+	}
+	if value, ok := field("color"); ok {
+		if seed.Color, err = parseHexColor(value); err != nil {
+			return Seed{}, fmt.Errorf("color in column %d: %w", indices["color"]+1, err)
+		}
+	}
+	if value, ok := field("label"); ok {
+		seed.Properties, _ = json.Marshal(map[string]string{"label": value})
+	}
+	return seed, nil
+}
+
+// Parse a color like #1173b9, with or without the #.
+func parseHexColor(value string) ([3]float32, error) {
+	hex := strings.TrimPrefix(value, "#")
+	rgb, err := strconv.ParseUint(hex, 16, 32)
+	if len(hex) != 6 || err != nil {
+		return [3]float32{}, fmt.Errorf("%q is not a hex color like #1173b9", value)
+	}
+	return [3]float32{
+		float32(rgb>>16&0xff) / 255,
+		float32(rgb>>8&0xff) / 255,
+		float32(rgb&0xff) / 255,
+	}, nil
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"voronoi/geom"
+)
+
+func TestReadCSVSeeds(t *testing.T) {
+	red := [3]float32{1, 0, 0}
+	tests := []struct {
+		name    string
+		input   string
+		comma   rune
+		columns string
+		want    []Seed
+	}{
+		{
+			name:  "no header",
+			input: "1,2\n3.5,-4\n",
+			comma: ',',
+			want: []Seed{
+				{Position: geom.Pt(1, 2), Color: paletteColor(0)},
+				{Position: geom.Pt(3.5, -4), Color: paletteColor(1)},
+			},
+		},
+		{
+			name:  "header",
+			input: "label,Y,x\nbrest,48.4,-4.5\n",
+			comma: ',',
+			want: []Seed{
+				{Position: geom.Pt(-4.5, 48.4), Color: paletteColor(0), Properties: json.RawMessage(`{"label":"brest"}`)},
+			},
+		},
+		{
+			name:    "columns by name",
+			input:   "name,lat,lon,w,colour\nbrest,48.4,-4.5,0.5,#ff0000\nnantes,47.2,-1.6,,\n",
+			comma:   ',',
+			columns: "x=lon, y=lat, weight=w, color=colour, label=name",
+			want: []Seed{
+				{Position: geom.Pt(-4.5, 48.4), Color: red, Weight: 0.5, Properties: json.RawMessage(`{"label":"brest"}`)},
+				{Position: geom.Pt(-1.6, 47.2), Color: paletteColor(1), Properties: json.RawMessage(`{"label":"nantes"}`)},
+			},
+		},
+		{
+			name:    "columns by number without header",
+			input:   "a,1,2,ff0000\n",
+			comma:   ',',
+			columns: "x=3,y=2,color=4,label=1",
+			want: []Seed{
+				{Position: geom.Pt(2, 1), Color: red, Properties: json.RawMessage(`{"label":"a"}`)},
+			},
+		},
+		{
+			name:    "columns by number with header",
+			input:   "label,lat,lon\nbrest,48.4,-4.5\n",
+			comma:   ',',
+			columns: "x=3,y=2",
+			want: []Seed{
+				{Position: geom.Pt(-4.5, 48.4), Color: paletteColor(0), Properties: json.RawMessage(`{"label":"brest"}`)},
+			},
+		},
+		{
+			name:  "tsv",
+			input: "x\ty\tlabel\r\n1\t2\t\"quoted\n# comment\n\n3\t4\t\n",
+			comma: '\t',
+			want: []Seed{
+				{Position: geom.Pt(1, 2), Color: paletteColor(0), Properties: json.RawMessage(`{"label":"\"quoted"}`)},
+				{Position: geom.Pt(3, 4), Color: paletteColor(1)},
+			},
+		},
+		{
+			name:  "empty",
+			input: "",
+			comma: ',',
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			seeds, err := readCSVSeeds(strings.NewReader(test.input), test.comma, test.columns)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(seeds, test.want) {
+				t.Errorf("got %+v, want %+v", seeds, test.want)
+			}
+		})
+	}
+}
+
+func TestReadCSVSeedsErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		comma   rune
+		columns string
+		want    string
+	}{
+		{"not a number", "x,y\n1,2\n3,abc\n", ',', "", `line 3: y "abc" in column 2 is not a number`},
+		{"after a comment", "# seeds\nx,y\n\n1,abc\n", ',', "", `line 4: y "abc" in column 2 is not a number`},
+		{"tsv after a comment", "# seeds\nx\ty\n\n1\tabc\n", '\t', "", `line 4: y "abc" in column 2 is not a number`},
+		{"missing y", "1,2\n3\n", ',', "", "line 2: no y in column 2"},
+		{"bad weight", "x,y,weight\n1,2,heavy\n", ',', "", `line 2: weight "heavy" in column 3 is not a number`},
+		{"bad color", "x,y,color\n1,2,red\n", ',', "", `line 2: color in column 3: "red" is not a hex color like #1173b9`},
+		{"no x in the header", "lon,lat\n1,2\n", ',', "", "line 1: no x column in the header, map it with -columns"},
+		{"mapped column missing", "lon,lat\n1,2\n", ',', "x=lon,y=latitude", `line 1: no column "latitude" in the header`},
+		{"unknown field", "1,2\n", ',', "x=1,z=2", `unknown field "z" in the column mapping, expected one of x, y, color, weight, label`},
+		{"not field=column", "1,2\n", ',', "x", `column mapping "x" is not field=column`},
+		{"column 0", "1,2\n", ',', "x=0", "column 0 of x, columns count from 1"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := readCSVSeeds(strings.NewReader(test.input), test.comma, test.columns)
+			if err == nil || err.Error() != test.want {
+				t.Errorf("got error %v, want %s", err, test.want)
+			}
+		})
+	}
+}
+
+func TestParseHexColor(t *testing.T) {
+	for _, value := range []string{"#1173b9", "1173b9", "#1173B9"} {
+		color, err := parseHexColor(value)
+		if want := [3]float32{0x11 / 255.0, 0x73 / 255.0, 0xb9 / 255.0}; err != nil || color != want {
+			t.Errorf("parseHexColor(%q) = %v, %v, want %v", value, color, err, want)
+		}
+	}
+	for _, value := range []string{"", "#", "#1173b", "#1173b9a", "#11x3b9", "##1173b9"} {
+		if _, err := parseHexColor(value); err == nil {
+			t.Errorf("parseHexColor(%q) succeeded", value)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"math"
+	"voronoi/geom"
+	"voronoi/glu"
+
+	"github.com/go-gl/glfw/v3.3/glfw"
+)
+
+// How close the cursor must be to a seed to drag or delete it, in screen coordinates
+const seedPickRadius = 12.0
+
+// Editing the seeds with the mouse, toggled with Tab. A left click adds a seed at the cursor,
+// or grabs the seed under it, and dragging moves the grabbed seed. A right click deletes the
+// seed under the cursor, and C gives it the next color of the palette. Every edit goes into
+// the history of the state, a whole drag as one.
+type seedEditor struct {
+	enabled bool
+	// Index of the seed being dragged, -1 when none
+	dragging int
+	// Where the dragged seed was when it was grabbed, and whether the click added it
+	dragStart geom.Point
+	dragAdded bool
+}
+
+var editor = seedEditor{dragging: -1}
+
+func (e *seedEditor) toggle() {
+	e.release()
+	e.enabled = !e.enabled
+}
+
+// Map a point of the drawing area, from (0,0) at the bottom left to (1,1) at the top right, to
+// seed coordinates through the view transform, like diagramPosition in seeds.glsl.
+func viewToSeed(view glu.Mat3, st geom.Point) geom.Point {
+	return geom.Pt(
+		float64(view[0])*st.X+float64(view[3])*st.Y+float64(view[6]),
+		float64(view[1])*st.X+float64(view[4])*st.Y+float64(view[7]),
+	)
+}
+
+// The inverse of viewToSeed. False if the view squashes the drawing area flat.
+func seedToView(view glu.Mat3, p geom.Point) (geom.Point, bool) {
+	a, b, c, d := float64(view[0]), float64(view[3]), float64(view[1]), float64(view[4])
+	det := a*d - b*c
+	if det == 0 {
+		return geom.Point{}, false
+	}
+	x, y := p.X-float64(view[6]), p.Y-float64(view[7])
+	return geom.Pt((d*x-b*y)/det, (a*y-c*x)/det), true
+}
+
+// The seed nearest to the cursor at (x, y) in screen coordinates, if it is within
+// seedPickRadius. -1 otherwise.
+func pickSeed(window *glfw.Window, view glu.Mat3, seeds []Seed, x, y float64) int {
+	width, height := window.GetSize()
+	picked, pickedDistance := -1, seedPickRadius
+	for i, seed := range seeds {
+		st, ok := seedToView(view, seed.Position)
+		if !ok {
+			return -1
+		}
+		// The drawing area goes from 0 to 1 over the window, with y up
+		dx := st.X*float64(width) - x
+		dy := (1-st.Y)*float64(height) - y
+		if distance := math.Hypot(dx, dy); distance <= pickedDistance {
+			picked, pickedDistance = i, distance
+		}
+	}
+	return picked
+}
+
+func mouseButtonCallback(window *glfw.Window, button glfw.MouseButton, action glfw.Action, mods glfw.ModifierKey) {
+	if !editor.enabled {
+		return
+	}
+	x, y := window.GetCursorPos()
+
+	switch {
+	case button == glfw.MouseButtonLeft && action == glfw.Press:
+		editor.dragging = pickSeed(window, state.view, state.seeds, x, y)
+		editor.dragAdded = editor.dragging < 0
+		if editor.dragAdded {
+			// Recorded on release, with the position it was dragged to
+			editor.dragging = state.addSeed(mouseSeedPosition(window, x, y))
+		}
+		editor.dragStart = state.seeds[editor.dragging].Position
+	case button == glfw.MouseButtonLeft && action == glfw.Release:
+		editor.release()
+	case button == glfw.MouseButtonRight && action == glfw.Press:
+		if i := pickSeed(window, state.view, state.seeds, x, y); i >= 0 {
+			editor.release()
+			state.history.execute(&state, deleteSeedCommand{index: i, seed: state.seeds[i]})
+		}
+	}
+}
+
+// Drop the dragged seed, if any, and record the drag.
+func (e *seedEditor) release() {
+	i := e.dragging
+	e.dragging = -1
+	if i < 0 || i >= len(state.seeds) {
+		return
+	}
+	if e.dragAdded {
+		state.history.record(addSeedCommand{index: i, seed: state.seeds[i]})
+	} else if position := state.seeds[i].Position; position != e.dragStart {
+		state.history.record(moveSeedCommand{index: i, from: e.dragStart, to: position})
+	}
+}
+
+// Give the seed under the cursor the next color of the palette.
+func (e *seedEditor) recolor(window *glfw.Window) {
+	x, y := window.GetCursorPos()
+	i := pickSeed(window, state.view, state.seeds, x, y)
+	if i < 0 {
+		return
+	}
+	from := state.seeds[i].Color
+	to := palette[0]
+	for j, color := range palette {
+		if color == from {
+			to = paletteColor(j + 1)
+		}
+	}
+	state.history.execute(&state, recolorSeedCommand{index: i, from: from, to: to})
+}
+
+// Move the dragged seed, if any, to the cursor. Called every frame with the cursor position in
+// the drawing area, see mouseDrawingPosition. The seed stays in the visible part of the
+// diagram.
+func (e *seedEditor) drag(mouse geom.Point) {
+	if e.dragging < 0 || e.dragging >= len(state.seeds) {
+		return
+	}
+	st := geom.Pt(min(max(mouse.X, 0), 1), min(max(mouse.Y, 0), 1))
+	state.moveSeed(e.dragging, viewToSeed(state.view, st))
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"voronoi/geom"
+)
+
+// A seedFrame maps the normalized seed coordinates to the coordinates of the file the seeds came
+// from, e.g. longitude and latitude, and back. The mapping only scales uniformly and translates,
+// so the diagram in seed coordinates is the diagram in file coordinates.
+type seedFrame struct {
+	// The file coordinates of the seed coordinates (0,0), and the file units per seed unit
+	origin geom.Point
+	scale  float64
+
+	// The area the cells are clipped to when they are written out, in file coordinates
+	bounds geom.Rect
+}
+
+// The frame of seeds which were not loaded from a file
+var unitFrame = seedFrame{scale: 1, bounds: unitRect}
+
+// A frame which fits bounds into the unit square, centered.
+func newSeedFrame(bounds geom.Rect) seedFrame {
+	scale := max(bounds.Dx(), bounds.Dy())
+	if scale == 0 {
+		scale = 1
+	}
+	center := bounds.Center()
+	return seedFrame{
+		origin: geom.Pt(center.X-scale/2, center.Y-scale/2),
+		scale:  scale,
+		bounds: bounds,
+	}
+}
+
+// Fraction of the extent of the points added around them by pointsBounds
+const boundsPadding = 0.05
+
+// The bounding box of the points with some room around them, so that the outer cells do not
+// end right at their seeds.
+func pointsBounds(points []geom.Point) geom.Rect {
+	if len(points) == 0 {
+		return unitRect
+	}
+	bounds := geom.Rect{Min: points[0], Max: points[0]}
+	for _, p := range points[1:] {
+		bounds.Min = geom.Pt(min(bounds.Min.X, p.X), min(bounds.Min.Y, p.Y))
+		bounds.Max = geom.Pt(max(bounds.Max.X, p.X), max(bounds.Max.Y, p.Y))
+	}
+	padding := max(bounds.Dx(), bounds.Dy()) * boundsPadding
+	if padding == 0 {
+		padding = 0.5
+	}
+	return geom.R(bounds.Min.X-padding, bounds.Min.Y-padding, bounds.Max.X+padding, bounds.Max.Y+padding)
+}
+
+func (f seedFrame) toSeed(p geom.Point) geom.Point {
+	return p.Sub(f.origin).Mul(1 / f.scale)
+}
+
+func (f seedFrame) toFile(p geom.Point) geom.Point {
+	return p.Mul(f.scale).Add(f.origin)
+}
+
+// The cells of the seeds in file coordinates, clipped to the bounds of the frame. Empty cells
+// are nil.
+func (f seedFrame) cells(seeds []Seed, metric geom.Metric, p float64, weighting geom.Weighting) ([][]geom.Point, error) {
+	bounds := geom.Rect{Min: f.toSeed(f.bounds.Min), Max: f.toSeed(f.bounds.Max)}
+	cells, err := geom.WeightedCells(seedPositions(seeds), seedWeights(seeds, weighting), bounds, weighting, metric, p)
+	if err != nil {
+		return nil, err
+	}
+	for _, cell := range cells {
+		for i := range cell {
+			cell[i] = f.toFile(cell[i])
+		}
+	}
+	return cells, nil
+}
+
+// Load seeds from a GeoJSON (.geojson or .json), WKT (.wkt), CSV (.csv) or TSV (.tsv) file, by
+// its extension. columns maps the columns of CSV and TSV files, see readCSVSeeds. The seeds are
+// fitted into the unit square, the returned frame maps them back.
+func loadSeeds(path string, columns string) ([]Seed, seedFrame, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, seedFrame{}, err
+	}
+	defer file.Close()
+
+	// In file coordinates, until the frame is known
+	var seeds []Seed
+	bounds := geom.Rect{}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".geojson", ".json":
+		var points []geom.Point
+		var properties []json.RawMessage
+		points, properties, bounds, err = readGeoJSONPoints(file)
+		seeds = newSeeds(points)
+		for i := range properties {
+			seeds[i].Properties = properties[i]
+		}
+	case ".wkt":
+		var points []geom.Point
+		points, err = readWKTPoints(file)
+		seeds = newSeeds(points)
+	case ".csv":
+		seeds, err = readCSVSeeds(file, ',', columns)
+	case ".tsv":
+		seeds, err = readCSVSeeds(file, '\t', columns)
+	default:
+		err = fmt.Errorf("unknown seed file type %q, expected .geojson, .json, .wkt, .csv or .tsv", ext)
+	}
+	if err != nil {
+		return nil, seedFrame{}, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(seeds) == 0 {
+		return nil, seedFrame{}, fmt.Errorf("%s: no points", path)
+	}
+
+	if bounds.Empty() {
+		bounds = pointsBounds(seedPositions(seeds))
+	}
+	frame := newSeedFrame(bounds)
+	for i := range seeds {
+		seeds[i].Position = frame.toSeed(seeds[i].Position)
+		seeds[i].Weight /= frame.scale
+	}
+	return seeds, frame, nil
+}
+
+// Write the cells of the seeds to a GeoJSON (.geojson or .json) or WKT (.wkt) file, by its
+// extension, in the coordinates of the frame.
+func exportCells(path string, seeds []Seed, frame seedFrame, metric geom.Metric, p float64, weighting geom.Weighting) error {
+	cells, err := frame.cells(seeds, metric, p, weighting)
+	if err != nil {
+		return err
+	}
+
+	var write func(file *os.File) error
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".geojson", ".json":
+		write = func(file *os.File) error { return writeGeoJSONCells(file, seeds, cells) }
+	case ".wkt":
+		write = func(file *os.File) error { return writeWKTCells(file, cells) }
+	default:
+		return fmt.Errorf("unknown cell file type %q, expected .geojson, .json or .wkt", ext)
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
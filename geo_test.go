@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"voronoi/geom"
+)
+
+// Seeds loaded from a file and their cells written back are in the coordinates of the file, and
+// the cells tile its bbox.
+func TestLoadSeedsExportCells(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "seeds.geojson")
+	input := `{"type": "FeatureCollection", "bbox": [-5, 47, -1, 49], "features": [
+		{"type": "Feature", "geometry": {"type": "MultiPoint", "coordinates": [[-4.5, 48.4], [-1.6, 47.2], [-2.8, 47.7]]}, "properties": {"region": "bretagne"}}
+	]}`
+	if err := os.WriteFile(path, []byte(input), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	seeds, frame, err := loadSeeds(path, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, seed := range seeds {
+		if p := seed.Position; p.X < 0 || p.X > 1 || p.Y < 0 || p.Y > 1 {
+			t.Errorf("seed %v is out of the unit square", p)
+		}
+	}
+
+	wktPath := filepath.Join(dir, "cells.wkt")
+	if err := exportCells(wktPath, seeds, frame, geom.Euclidean, geom.DefaultMinkowskiP, geom.Unweighted); err != nil {
+		t.Fatal(err)
+	}
+	geoJSONPath := filepath.Join(dir, "cells.geojson")
+	if err := exportCells(geoJSONPath, seeds, frame, geom.Euclidean, geom.DefaultMinkowskiP, geom.Unweighted); err != nil {
+		t.Fatal(err)
+	}
+
+	text, err := os.ReadFile(wktPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSpace(string(text)), "\n"); len(lines) != len(seeds) {
+		t.Errorf("%d WKT polygons for %d seeds", len(lines), len(seeds))
+	}
+
+	file, err := os.Open(geoJSONPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	var collection geoJSONFeatureCollection
+	if err := json.NewDecoder(file).Decode(&collection); err != nil {
+		t.Fatal(err)
+	}
+	if len(collection.Features) != len(seeds) {
+		t.Fatalf("%d features for %d seeds", len(collection.Features), len(seeds))
+	}
+	total := 0.0
+	for i, feature := range collection.Features {
+		if string(feature.Properties) != `{"region":"bretagne"}` {
+			t.Errorf("feature %d has the properties %s", i, feature.Properties)
+		}
+		var rings [][][2]float64
+		if err := json.Unmarshal(feature.Geometry.Coordinates, &rings); err != nil {
+			t.Fatal(err)
+		}
+		ring := rings[0]
+		if ring[0] != ring[len(ring)-1] {
+			t.Errorf("the ring of feature %d is not closed", i)
+		}
+		cell := make([]geom.Point, len(ring)-1)
+		for j := range cell {
+			cell[j] = geom.Pt(ring[j][0], ring[j][1])
+		}
+		total += geom.Area(cell)
+	}
+	if math.Abs(total-8) > 1e-9 {
+		t.Errorf("the cells cover %v, the bbox 8", total)
+	}
+
+	if err := exportCells(filepath.Join(dir, "cells.svg"), seeds, frame, geom.Euclidean, 3, geom.Unweighted); err == nil {
+		t.Error("exported cells into an .svg")
+	}
+	if _, _, err := loadSeeds(filepath.Join(dir, "cells.wkt"), ""); err == nil {
+		t.Error("loaded seeds from a file of polygons")
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"voronoi/geom"
+)
+
+// The parts of GeoJSON (RFC 7946) which the seeds and cells need
+
+type geoJSONFeatureCollection struct {
+	Type     string           `json:"type"`
+	BBox     []float64        `json:"bbox,omitempty"`
+	Features []geoJSONFeature `json:"features"`
+}
+
+type geoJSONFeature struct {
+	Type       string           `json:"type"`
+	ID         any              `json:"id,omitempty"`
+	Geometry   *geoJSONGeometry `json:"geometry"`
+	Properties json.RawMessage  `json:"properties"`
+}
+
+type geoJSONGeometry struct {
+	Type        string          `json:"type"`
+	Coordinates json.RawMessage `json:"coordinates"`
+}
+
+// Read the Point and MultiPoint features of a GeoJSON FeatureCollection. Returns the points, the
+// properties of the feature of every point, and the bbox of the collection, which is empty if
+// it has none. Features without a geometry are skipped.
+func readGeoJSONPoints(r io.Reader) ([]geom.Point, []json.RawMessage, geom.Rect, error) {
+	var collection geoJSONFeatureCollection
+	if err := json.NewDecoder(r).Decode(&collection); err != nil {
+		return nil, nil, geom.Rect{}, err
+	}
+	if collection.Type != "FeatureCollection" {
+		return nil, nil, geom.Rect{}, fmt.Errorf("expected a FeatureCollection, not %q", collection.Type)
+	}
+
+	var points []geom.Point
+	var properties []json.RawMessage
+	for i, feature := range collection.Features {
+		if feature.Geometry == nil {
+			continue
+		}
+		var coordinates [][]float64
+		var err error
+		switch feature.Geometry.Type {
+		case "Point":
+			var position []float64
+			err = json.Unmarshal(feature.Geometry.Coordinates, &position)
+			coordinates = [][]float64{position}
+		case "MultiPoint":
+			err = json.Unmarshal(feature.Geometry.Coordinates, &coordinates)
+		default:
+			err = fmt.Errorf("%s geometry, only Point and MultiPoint are supported", feature.Geometry.Type)
+		}
+		if err != nil {
+			return nil, nil, geom.Rect{}, fmt.Errorf("feature %d: %w", i, err)
+		}
+		for _, position := range coordinates {
+			// Positions may have an altitude, which is ignored
+			if len(position) < 2 {
+				return nil, nil, geom.Rect{}, fmt.Errorf("feature %d: position with %d coordinates", i, len(position))
+			}
+			points = append(points, geom.Pt(position[0], position[1]))
+			properties = append(properties, feature.Properties)
+		}
+	}
+
+	var bounds geom.Rect
+	switch len(collection.BBox) {
+	case 4:
+		bounds = geom.R(collection.BBox[0], collection.BBox[1], collection.BBox[2], collection.BBox[3])
+	case 6:
+		bounds = geom.R(collection.BBox[0], collection.BBox[1], collection.BBox[3], collection.BBox[4])
+	}
+	return points, properties, bounds, nil
+}
+
+// Write the cells as a GeoJSON FeatureCollection of Polygons, one feature per seed in the same
+// order. The id of every feature is the index of its seed, and the properties are those of the
+// feature the seed was loaded from. Empty cells have a null geometry.
+func writeGeoJSONCells(w io.Writer, seeds []Seed, cells [][]geom.Point) error {
+	collection := geoJSONFeatureCollection{
+		Type:     "FeatureCollection",
+		Features: make([]geoJSONFeature, len(cells)),
+	}
+	for i, cell := range cells {
+		feature := geoJSONFeature{Type: "Feature", ID: i, Properties: seeds[i].Properties}
+		if len(feature.Properties) == 0 {
+			feature.Properties = json.RawMessage("null")
+		}
+		if len(cell) > 0 {
+			// The cells go counter-clockwise, as the exterior rings of GeoJSON should, but the
+			// rings must be closed
+			ring := make([][2]float64, 0, len(cell)+1)
+			for j := 0; j <= len(cell); j++ {
+				p := cell[j%len(cell)]
+				ring = append(ring, [2]float64{p.X, p.Y})
+			}
+			coordinates, err := json.Marshal([][][2]float64{ring})
+			if err != nil {
+				return err
+			}
+			feature.Geometry = &geoJSONGeometry{Type: "Polygon", Coordinates: coordinates}
+		}
+		collection.Features[i] = feature
+	}
+
+	out := bufio.NewWriter(w)
+	encoder := json.NewEncoder(out)
+	if err := encoder.Encode(collection); err != nil {
+		return err
+	}
+	return out.Flush()
+}
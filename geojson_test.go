@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"voronoi/geom"
+)
+
+func TestReadGeoJSONPoints(t *testing.T) {
+	input := `{
+		"type": "FeatureCollection",
+		"bbox": [-5, 47, 0, -2, 49, 100],
+		"features": [
+			{"type": "Feature", "geometry": {"type": "Point", "coordinates": [-4.5, 48.4]}, "properties": {"name": "brest"}},
+			{"type": "Feature", "geometry": null, "properties": {"name": "nowhere"}},
+			{"type": "Feature", "geometry": {"type": "MultiPoint", "coordinates": [[-1.6, 47.2, 10], [-2.8, 47.7]]}, "properties": null}
+		]
+	}`
+	points, properties, bounds, err := readGeoJSONPoints(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []geom.Point{geom.Pt(-4.5, 48.4), geom.Pt(-1.6, 47.2), geom.Pt(-2.8, 47.7)}; !reflect.DeepEqual(points, want) {
+		t.Errorf("points %v, want %v", points, want)
+	}
+	wantProperties := []string{`{"name": "brest"}`, "null", "null"}
+	for i, p := range properties {
+		if string(p) != wantProperties[i] {
+			t.Errorf("properties of point %d are %s, want %s", i, p, wantProperties[i])
+		}
+	}
+	if want := geom.R(-5, 47, -2, 49); bounds != want {
+		t.Errorf("bounds %v, want %v", bounds, want)
+	}
+
+	_, _, bounds, err = readGeoJSONPoints(strings.NewReader(`{"type": "FeatureCollection", "features": []}`))
+	if err != nil || !bounds.Empty() {
+		t.Errorf("without a bbox: bounds %v, error %v, want empty bounds", bounds, err)
+	}
+}
+
+func TestReadGeoJSONPointsErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{`{"type": "Feature"}`, `expected a FeatureCollection, not "Feature"`},
+		{`{"type": "FeatureCollection", "features": [{"type": "Feature", "geometry": {"type": "LineString", "coordinates": [[0, 0], [1, 1]]}}]}`,
+			"feature 0: LineString geometry, only Point and MultiPoint are supported"},
+		{`{"type": "FeatureCollection", "features": [{"geometry": null}, {"geometry": {"type": "Point", "coordinates": [1]}}]}`,
+			"feature 1: position with 1 coordinates"},
+	}
+	for _, test := range tests {
+		_, _, _, err := readGeoJSONPoints(strings.NewReader(test.input))
+		if err == nil || err.Error() != test.want {
+			t.Errorf("readGeoJSONPoints(%s): got error %v, want %s", test.input, err, test.want)
+		}
+	}
+}
+
+func TestWriteGeoJSONCells(t *testing.T) {
+	seeds := []Seed{
+		{Properties: json.RawMessage(`{"name":"brest"}`)},
+		{},
+		{},
+	}
+	cells := [][]geom.Point{
+		{geom.Pt(0, 0), geom.Pt(0.5, 0), geom.Pt(0.5, 1), geom.Pt(0, 1)},
+		nil,
+		{geom.Pt(0.5, 0), geom.Pt(1, 0), geom.Pt(1, 1)},
+	}
+	var out bytes.Buffer
+	if err := writeGeoJSONCells(&out, seeds, cells); err != nil {
+		t.Fatal(err)
+	}
+	want := `{"type":"FeatureCollection","features":[` +
+		`{"type":"Feature","id":0,"geometry":{"type":"Polygon","coordinates":[[[0,0],[0.5,0],[0.5,1],[0,1],[0,0]]]},"properties":{"name":"brest"}},` +
+		`{"type":"Feature","id":1,"geometry":null,"properties":null},` +
+		`{"type":"Feature","id":2,"geometry":{"type":"Polygon","coordinates":[[[0.5,0],[1,0],[1,1],[0.5,0]]]},"properties":null}]}` + "\n"
+	if out.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", out.String(), want)
+	}
+}
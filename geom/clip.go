@@ -0,0 +1,324 @@
+package geom
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Connect dangling edges (edges with only a start vertex, or none) to the bounding rectangle.
+// Returns false if the edge does not intersect the rectangle at all.
+func (s *sweep) connectEdge(e *edge, bounds Rect) bool {
+	vb := e.vb
+	if vb != nil {
+		return true
+	}
+
+	va := e.va
+	xl, xr := bounds.Min.X, bounds.Max.X
+	yt, yb := bounds.Min.Y, bounds.Max.Y
+	lSite, rSite := e.lSite, e.rSite
+	lx, ly := lSite.X, lSite.Y
+	rx, ry := rSite.X, rSite.Y
+	fx, fy := (lx+rx)/2, (ly+ry)/2
+
+	// The cells of both sites need closing since one of their edges hits the border
+	s.cells[lSite.index].closeMe = true
+	s.cells[rSite.index].closeMe = true
+
+	if ry == ly {
+		// Vertical bisector
+		if fx < xl || fx >= xr {
+			return false
+		}
+		if lx > rx {
+			if va == nil || va.Y < yt {
+				va = newVertex(fx, yt)
+			} else if va.Y >= yb {
+				return false
+			}
+			vb = newVertex(fx, yb)
+		} else {
+			if va == nil || va.Y > yb {
+				va = newVertex(fx, yb)
+			} else if va.Y < yt {
+				return false
+			}
+			vb = newVertex(fx, yt)
+		}
+	} else {
+		// Slope and intercept of the bisector
+		fm := (lx - rx) / (ry - ly)
+		fb := fy - fm*fx
+		if fm < -1 || fm > 1 {
+			// Closer to vertical than horizontal, connect to top and bottom
+			if lx > rx {
+				if va == nil || va.Y < yt {
+					va = newVertex((yt-fb)/fm, yt)
+				} else if va.Y >= yb {
+					return false
+				}
+				vb = newVertex((yb-fb)/fm, yb)
+			} else {
+				if va == nil || va.Y > yb {
+					va = newVertex((yb-fb)/fm, yb)
+				} else if va.Y < yt {
+					return false
+				}
+				vb = newVertex((yt-fb)/fm, yt)
+			}
+		} else {
+			// Closer to horizontal than vertical, connect to left and right
+			if ly < ry {
+				if va == nil || va.X < xl {
+					va = newVertex(xl, fm*xl+fb)
+				} else if va.X >= xr {
+					return false
+				}
+				vb = newVertex(xr, fm*xr+fb)
+			} else {
+				if va == nil || va.X > xr {
+					va = newVertex(xr, fm*xr+fb)
+				} else if va.X < xl {
+					return false
+				}
+				vb = newVertex(xl, fm*xl+fb)
+			}
+		}
+	}
+	e.va = va
+	e.vb = vb
+	return true
+}
+
+// Clip an edge to the bounding rectangle with the Liang-Barsky algorithm. Returns false if the
+// edge lies completely outside.
+func (s *sweep) clipEdge(e *edge, bounds Rect) bool {
+	ax, ay := e.va.X, e.va.Y
+	bx, by := e.vb.X, e.vb.Y
+	t0, t1 := 0.0, 1.0
+	dx, dy := bx-ax, by-ay
+
+	// left
+	q := ax - bounds.Min.X
+	if dx == 0 && q < 0 {
+		return false
+	}
+	r := -q / dx
+	if dx < 0 {
+		if r < t0 {
+			return false
+		}
+		if r < t1 {
+			t1 = r
+		}
+	} else if dx > 0 {
+		if r > t1 {
+			return false
+		}
+		if r > t0 {
+			t0 = r
+		}
+	}
+
+	// right
+	q = bounds.Max.X - ax
+	if dx == 0 && q < 0 {
+		return false
+	}
+	r = q / dx
+	if dx < 0 {
+		if r > t1 {
+			return false
+		}
+		if r > t0 {
+			t0 = r
+		}
+	} else if dx > 0 {
+		if r < t0 {
+			return false
+		}
+		if r < t1 {
+			t1 = r
+		}
+	}
+
+	// top
+	q = ay - bounds.Min.Y
+	if dy == 0 && q < 0 {
+		return false
+	}
+	r = -q / dy
+	if dy < 0 {
+		if r < t0 {
+			return false
+		}
+		if r < t1 {
+			t1 = r
+		}
+	} else if dy > 0 {
+		if r > t1 {
+			return false
+		}
+		if r > t0 {
+			t0 = r
+		}
+	}
+
+	// bottom
+	q = bounds.Max.Y - ay
+	if dy == 0 && q < 0 {
+		return false
+	}
+	r = q / dy
+	if dy < 0 {
+		if r > t1 {
+			return false
+		}
+		if r > t0 {
+			t0 = r
+		}
+	} else if dy > 0 {
+		if r < t0 {
+			return false
+		}
+		if r < t1 {
+			t1 = r
+		}
+	}
+
+	// The edge is (at least partially) inside. Move the endpoints which are outside.
+	if t0 > 0 {
+		e.va = newVertex(ax+t0*dx, ay+t0*dy)
+	}
+	if t1 < 1 {
+		e.vb = newVertex(ax+t1*dx, ay+t1*dy)
+	}
+	if t0 > 0 || t1 < 1 {
+		s.cells[e.lSite.index].closeMe = true
+		s.cells[e.rSite.index].closeMe = true
+	}
+	return true
+}
+
+// Connect and clip all the edges, dropping the ones which end up outside of the bounds or
+// collapsed to a point.
+func (s *sweep) clipEdges(bounds Rect) {
+	kept := s.edges[:0]
+	for _, e := range s.edges {
+		if !s.connectEdge(e, bounds) ||
+			!s.clipEdge(e, bounds) ||
+			(math.Abs(e.va.X-e.vb.X) < s.epsilon && math.Abs(e.va.Y-e.vb.Y) < s.epsilon) {
+			e.va = nil
+			e.vb = nil
+			continue
+		}
+		kept = append(kept, e)
+	}
+	for i := len(kept); i < len(s.edges); i++ {
+		s.edges[i] = nil
+	}
+	s.edges = kept
+}
+
+// Drop the halfedges of a cell whose edge was clipped away and sort the rest by angle.
+// Returns the number of halfedges left.
+func (c *cell) prepareHalfedges() int {
+	kept := c.halfedges[:0]
+	for _, h := range c.halfedges {
+		if h.edge.va != nil && h.edge.vb != nil {
+			kept = append(kept, h)
+		}
+	}
+	c.halfedges = kept
+	sort.SliceStable(c.halfedges, func(i, j int) bool {
+		return c.halfedges[i].angle > c.halfedges[j].angle
+	})
+	return len(c.halfedges)
+}
+
+// Sides of the bounding rectangle, in the order in which closeCells walks around it.
+const (
+	sideLeft = iota
+	sideBottom
+	sideRight
+	sideTop
+)
+
+// Returned when rounding errors left a cell which cannot be closed along the bounding rectangle
+var ErrOpenCell = errors.New("geom: cell cannot be closed along the bounding rectangle")
+
+// Close the cells which were cut open by the bounding rectangle by adding border edges.
+func (s *sweep) closeCells(bounds Rect) error {
+	xl, xr := bounds.Min.X, bounds.Max.X
+	yt, yb := bounds.Min.Y, bounds.Max.Y
+
+	for _, c := range s.cells {
+		if c == nil || c.prepareHalfedges() == 0 || !c.closeMe {
+			continue
+		}
+
+		for iLeft := 0; iLeft < len(c.halfedges); iLeft++ {
+			va := c.halfedges[iLeft].endpoint()
+			vz := c.halfedges[(iLeft+1)%len(c.halfedges)].startpoint()
+
+			// If the end point of one halfedge is not the start point of the next one there is a
+			// gap, which must lie on the border. Walk around the border until we reach vz.
+			if math.Abs(va.X-vz.X) < s.epsilon && math.Abs(va.Y-vz.Y) < s.epsilon {
+				continue
+			}
+
+			var side int
+			switch {
+			case s.equalWithEpsilon(va.X, xl) && s.lessThanWithEpsilon(va.Y, yb):
+				side = sideLeft
+			case s.equalWithEpsilon(va.Y, yb) && s.lessThanWithEpsilon(va.X, xr):
+				side = sideBottom
+			case s.equalWithEpsilon(va.X, xr) && s.greaterThanWithEpsilon(va.Y, yt):
+				side = sideRight
+			case s.equalWithEpsilon(va.Y, yt) && s.greaterThanWithEpsilon(va.X, xl):
+				side = sideTop
+			default:
+				return fmt.Errorf("%w: the gap in cell %d does not start on it", ErrOpenCell, c.site.index)
+			}
+
+			for step := 0; ; step++ {
+				if step > 4 {
+					return fmt.Errorf("%w: the gap in cell %d does not close along it", ErrOpenCell, c.site.index)
+				}
+				var last bool
+				var vb *vertex
+				switch side {
+				case sideLeft:
+					last = s.equalWithEpsilon(vz.X, xl)
+					vb = newVertex(xl, yb)
+				case sideBottom:
+					last = s.equalWithEpsilon(vz.Y, yb)
+					vb = newVertex(xr, yb)
+				case sideRight:
+					last = s.equalWithEpsilon(vz.X, xr)
+					vb = newVertex(xr, yt)
+				case sideTop:
+					last = s.equalWithEpsilon(vz.Y, yt)
+					vb = newVertex(xl, yt)
+				}
+				if last {
+					vb = vz
+				}
+				e := s.createBorderEdge(c.site, va, vb)
+				iLeft++
+				c.halfedges = append(c.halfedges, nil)
+				copy(c.halfedges[iLeft+1:], c.halfedges[iLeft:])
+				c.halfedges[iLeft] = newHalfedge(e, c.site, nil)
+				if last {
+					break
+				}
+				va = vb
+				side = (side + 1) % 4
+			}
+		}
+		c.closeMe = false
+	}
+	return nil
+}
@@ -0,0 +1,232 @@
+package geom
+
+import "sort"
+
+// A Triangulation is the Delaunay triangulation of a set of points, the dual of their Voronoi
+// diagram. The layout is a flat half-edge structure: half-edge e goes from point
+// Triangles[e] to point Triangles[NextHalfEdge(e)] and belongs to triangle e/3.
+type Triangulation struct {
+	Points []Point
+
+	// Three point indices per triangle, counter-clockwise.
+	Triangles []int
+
+	// HalfEdges[e] is the half-edge going the other way in the adjacent triangle, or -1 if e
+	// lies on the convex hull.
+	HalfEdges []int
+
+	// Point indices of the convex hull, counter-clockwise.
+	Hull []int
+}
+
+// Compute the Delaunay triangulation of the given points. It comes out of the same sweep as
+// NewDiagram, without the clipping.
+func NewTriangulation(points []Point) *Triangulation {
+	s := runSweep(points, sweepScale(pointsRect(points)))
+	return newTriangulation(points, s.triangles)
+}
+
+// Delaunay returns the Delaunay triangulation of the sites of the diagram. Unlike the cells it
+// is not clipped to the bounds.
+func (d *Diagram) Delaunay() *Triangulation {
+	return newTriangulation(d.Sites, d.triangles)
+}
+
+// Voronoi returns the Voronoi diagram of the points of the triangulation, clipped to bounds.
+// See NewDiagram.
+func (t *Triangulation) Voronoi(bounds Rect) (*Diagram, error) {
+	return NewDiagram(t.Points, bounds)
+}
+
+// The smallest rectangle containing the points
+func pointsRect(points []Point) Rect {
+	if len(points) == 0 {
+		return Rect{}
+	}
+	r := Rect{points[0], points[0]}
+	for _, p := range points[1:] {
+		r.Min = Point{min(r.Min.X, p.X), min(r.Min.Y, p.Y)}
+		r.Max = Point{max(r.Max.X, p.X), max(r.Max.Y, p.Y)}
+	}
+	return r
+}
+
+func newTriangulation(points []Point, triangles []int) *Triangulation {
+	t := &Triangulation{
+		Points:    append([]Point(nil), points...),
+		Triangles: append([]int(nil), triangles...),
+		HalfEdges: make([]int, len(triangles)),
+	}
+
+	// Make all the triangles counter-clockwise
+	for i := 0; i < len(t.Triangles); i += 3 {
+		a, b, c := points[t.Triangles[i]], points[t.Triangles[i+1]], points[t.Triangles[i+2]]
+		if orient(a, b, c) < 0 {
+			t.Triangles[i+1], t.Triangles[i+2] = t.Triangles[i+2], t.Triangles[i+1]
+		}
+	}
+
+	// Pair up the half-edges
+	type key struct{ from, to int }
+	edges := make(map[key]int, len(t.Triangles))
+	for e := range t.Triangles {
+		edges[key{t.Triangles[e], t.Triangles[NextHalfEdge(e)]}] = e
+	}
+	for e := range t.Triangles {
+		if opposite, ok := edges[key{t.Triangles[NextHalfEdge(e)], t.Triangles[e]}]; ok {
+			t.HalfEdges[e] = opposite
+		} else {
+			t.HalfEdges[e] = -1
+		}
+	}
+
+	t.Hull = t.hull()
+	return t
+}
+
+// Walk the unpaired half-edges around the outside of the triangulation.
+func (t *Triangulation) hull() []int {
+	next := make(map[int]int)
+	start := -1
+	for e, opposite := range t.HalfEdges {
+		if opposite < 0 {
+			from := t.Triangles[e]
+			next[from] = t.Triangles[NextHalfEdge(e)]
+			if start < 0 {
+				start = from
+			}
+		}
+	}
+
+	if start < 0 {
+		return degenerateHull(t.Points)
+	}
+
+	hull := []int{start}
+	for p := next[start]; p != start && len(hull) <= len(next); p = next[p] {
+		hull = append(hull, p)
+	}
+	return hull
+}
+
+// Without any triangles all the points are collinear (or there are fewer than three of them).
+// The hull is then the distinct points in order along the line. Of duplicate points it keeps
+// the last, which is the one the sweep gives the cell to.
+func degenerateHull(points []Point) []int {
+	order := make([]int, len(points))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := points[order[i]], points[order[j]]
+		if a.X != b.X {
+			return a.X < b.X
+		}
+		return a.Y < b.Y
+	})
+	hull := make([]int, 0, len(order))
+	for _, i := range order {
+		if len(hull) > 0 && points[hull[len(hull)-1]] == points[i] {
+			hull[len(hull)-1] = i
+			continue
+		}
+		hull = append(hull, i)
+	}
+	return hull
+}
+
+// Twice the signed area of the triangle abc. Positive if abc is counter-clockwise.
+func orient(a, b, c Point) float64 {
+	return (b.X-a.X)*(c.Y-a.Y) - (b.Y-a.Y)*(c.X-a.X)
+}
+
+// NextHalfEdge returns the half-edge following e in its triangle.
+func NextHalfEdge(e int) int {
+	if e%3 == 2 {
+		return e - 2
+	}
+	return e + 1
+}
+
+// PrevHalfEdge returns the half-edge preceding e in its triangle.
+func PrevHalfEdge(e int) int {
+	if e%3 == 0 {
+		return e + 2
+	}
+	return e - 1
+}
+
+// Len returns the number of triangles.
+func (t *Triangulation) Len() int {
+	return len(t.Triangles) / 3
+}
+
+// Triangle returns the corners of triangle i.
+func (t *Triangulation) Triangle(i int) [3]Point {
+	return [3]Point{
+		t.Points[t.Triangles[3*i]],
+		t.Points[t.Triangles[3*i+1]],
+		t.Points[t.Triangles[3*i+2]],
+	}
+}
+
+// Circumcenter returns the centre of the circumscribed circle of triangle i, which is the
+// corresponding vertex of the Voronoi diagram.
+func (t *Triangulation) Circumcenter(i int) Point {
+	tri := t.Triangle(i)
+	a, b, c := tri[0], tri[1].Sub(tri[0]), tri[2].Sub(tri[0])
+	d := 2 * (b.X*c.Y - b.Y*c.X)
+	hb := b.X*b.X + b.Y*b.Y
+	hc := c.X*c.X + c.Y*c.Y
+	return Point{(c.Y*hb-b.Y*hc)/d + a.X, (b.X*hc-c.X*hb)/d + a.Y}
+}
+
+// Edges returns every edge of the triangulation once, as pairs of point indices. Without any
+// triangles, when the points are collinear, the edges join the points of the hull in order
+// along the line.
+func (t *Triangulation) Edges() [][2]int {
+	if len(t.Triangles) == 0 {
+		edges := make([][2]int, 0, len(t.Hull))
+		for i := 1; i < len(t.Hull); i++ {
+			edges = append(edges, [2]int{t.Hull[i-1], t.Hull[i]})
+		}
+		return edges
+	}
+	edges := make([][2]int, 0, len(t.Triangles)/2+len(t.Hull))
+	for e, opposite := range t.HalfEdges {
+		if e > opposite {
+			edges = append(edges, [2]int{t.Triangles[e], t.Triangles[NextHalfEdge(e)]})
+		}
+	}
+	return edges
+}
+
+// Neighbors returns the indices of the points connected to point i by an edge, see Edges.
+func (t *Triangulation) Neighbors(i int) []int {
+	var neighbors []int
+	if len(t.Triangles) == 0 {
+		for j, p := range t.Hull {
+			if p != i {
+				continue
+			}
+			if j > 0 {
+				neighbors = append(neighbors, t.Hull[j-1])
+			}
+			if j+1 < len(t.Hull) {
+				neighbors = append(neighbors, t.Hull[j+1])
+			}
+		}
+		return neighbors
+	}
+	for e, p := range t.Triangles {
+		if p != i {
+			continue
+		}
+		neighbors = append(neighbors, t.Triangles[NextHalfEdge(e)])
+		// Edges on the hull only show up once from this side
+		if t.HalfEdges[PrevHalfEdge(e)] < 0 {
+			neighbors = append(neighbors, t.Triangles[PrevHalfEdge(e)])
+		}
+	}
+	return neighbors
+}
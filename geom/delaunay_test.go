@@ -0,0 +1,153 @@
+package geom
+
+import (
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// The edges as a set, each with the smaller point index first
+func edgeSet(edges [][2]int) map[[2]int]bool {
+	set := map[[2]int]bool{}
+	for _, e := range edges {
+		set[[2]int{min(e[0], e[1]), max(e[0], e[1])}] = true
+	}
+	return set
+}
+
+// No point is inside the circumcircle of a triangle.
+func TestTriangulationEmptyCircumcircle(t *testing.T) {
+	rng := rand.New(rand.NewSource(5))
+	for _, n := range []int{3, 10, 200} {
+		points := randomSites(rng, n, unitSquare)
+		tr := NewTriangulation(points)
+		if tr.Len() == 0 {
+			t.Fatalf("%d points: no triangles", n)
+		}
+		for i := 0; i < tr.Len(); i++ {
+			tri := tr.Triangle(i)
+			if orient(tri[0], tri[1], tri[2]) <= 0 {
+				t.Errorf("%d points: triangle %d %v is not counter-clockwise", n, i, tri)
+			}
+			center := tr.Circumcenter(i)
+			radius := center.Dist(tri[0])
+			for j, p := range points {
+				if d := center.Dist(p); d < radius*(1-1e-9) {
+					t.Errorf("%d points: point %d is inside the circumcircle of triangle %d, %v from its center, radius %v", n, j, i, d, radius)
+				}
+			}
+		}
+	}
+}
+
+// The Delaunay edges join the sites whose Voronoi cells share an edge, when the bounds are far
+// enough that they cut no Voronoi edge off.
+func TestTriangulationEdgesMatchVoronoi(t *testing.T) {
+	rng := rand.New(rand.NewSource(6))
+	sites := randomSites(rng, 100, unitSquare)
+	d, err := NewDiagram(sites, R(-1e3, -1e3, 1e3, 1e3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := d.Delaunay()
+
+	neighbors := map[[2]int]bool{}
+	for i, cell := range d.Cells {
+		for _, j := range cell.Neighbors() {
+			neighbors[[2]int{min(i, j), max(i, j)}] = true
+		}
+	}
+	edges := edgeSet(tr.Edges())
+	if !reflect.DeepEqual(edges, neighbors) {
+		t.Errorf("%d Delaunay edges, %d pairs of Voronoi neighbors", len(edges), len(neighbors))
+		for e := range edges {
+			if !neighbors[e] {
+				t.Errorf("the cells of the Delaunay edge %v are not neighbors", e)
+			}
+		}
+		for e := range neighbors {
+			if !edges[e] {
+				t.Errorf("no Delaunay edge between the neighbors %v", e)
+			}
+		}
+	}
+	if again := edgeSet(NewTriangulation(sites).Edges()); !reflect.DeepEqual(again, edges) {
+		t.Error("NewTriangulation has other edges than Diagram.Delaunay")
+	}
+	for i := range sites {
+		for _, j := range tr.Neighbors(i) {
+			if !edges[[2]int{min(i, j), max(i, j)}] {
+				t.Errorf("point %d has the neighbor %d without an edge between them", i, j)
+			}
+		}
+	}
+}
+
+// Without triangles, the hull and the edges follow the points along the line.
+func TestTriangulationDegenerate(t *testing.T) {
+	tests := []struct {
+		name   string
+		points []Point
+		hull   []int
+	}{
+		{"none", nil, []int{}},
+		{"one", []Point{{0.5, 0.5}}, []int{0}},
+		{"two", []Point{{0.8, 0.2}, {0.1, 0.6}}, []int{1, 0}},
+		{"horizontal", []Point{{0.5, 0.5}, {0.1, 0.5}, {0.9, 0.5}, {0.3, 0.5}}, []int{1, 3, 0, 2}},
+		{"vertical", []Point{{0.5, 0.9}, {0.5, 0.1}, {0.5, 0.4}}, []int{1, 2, 0}},
+		{"diagonal with duplicates", []Point{{0.6, 0.6}, {0.2, 0.2}, {0.6, 0.6}, {0.4, 0.4}}, []int{1, 3, 2}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tr := NewTriangulation(test.points)
+			if tr.Len() != 0 {
+				t.Fatalf("%d triangles", tr.Len())
+			}
+			if !reflect.DeepEqual(tr.Hull, test.hull) {
+				t.Errorf("hull %v, want %v", tr.Hull, test.hull)
+			}
+			var want [][2]int
+			for i := 1; i < len(test.hull); i++ {
+				want = append(want, [2]int{test.hull[i-1], test.hull[i]})
+			}
+			if edges := tr.Edges(); !reflect.DeepEqual(edgeSet(edges), edgeSet(want)) {
+				t.Errorf("edges %v, want %v", edges, want)
+			}
+			for j, p := range test.hull {
+				var want []int
+				if j > 0 {
+					want = append(want, test.hull[j-1])
+				}
+				if j+1 < len(test.hull) {
+					want = append(want, test.hull[j+1])
+				}
+				got := tr.Neighbors(p)
+				sort.Ints(got)
+				sort.Ints(want)
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("point %d has the neighbors %v, want %v", p, got, want)
+				}
+			}
+
+			// The Voronoi cells of the points are strips across the line, each next to the cells
+			// of the neighbors of its point
+			if len(test.points) == 0 {
+				return
+			}
+			d, err := tr.Voronoi(unitSquare)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, p := range test.hull {
+				got := d.Cells[p].Neighbors()
+				sort.Ints(got)
+				want := tr.Neighbors(p)
+				sort.Ints(want)
+				if len(got) != len(want) || (len(want) > 0 && !reflect.DeepEqual(got, want)) {
+					t.Errorf("the cell of point %d has the neighbors %v, the triangulation %v", p, got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,193 @@
+package geom
+
+// A Diagram is the Voronoi diagram of a set of sites, clipped to a bounding rectangle.
+// All the cross references are indices into the slices of the diagram.
+type Diagram struct {
+	Bounds Rect
+	Sites  []Point
+
+	// Cells[i] is the cell of Sites[i]. Duplicate sites, and sites whose cell lies completely
+	// outside of the bounds, have a cell with no halfedges.
+	Cells    []Cell
+	Edges    []Edge
+	Vertices []Vertex
+
+	// The Delaunay triangles recorded during the sweep. See Delaunay.
+	triangles []int
+}
+
+// A Cell is the region of the plane closer to its site than to any other site. The halfedges go
+// around the cell counter-clockwise.
+type Cell struct {
+	Site      int
+	HalfEdges []HalfEdge
+}
+
+// A HalfEdge is an Edge as seen from one of the two cells it separates.
+type HalfEdge struct {
+	Edge       int
+	Start, End int // vertex indices
+	Neighbor   int // the site on the other side of the edge, or -1 for the bounding rectangle
+}
+
+// An Edge separates the cells of the sites Left and Right. Edges along the bounding rectangle
+// only have a Left site and Right is -1.
+type Edge struct {
+	Left, Right int
+	A, B        int // vertex indices
+}
+
+// A Vertex is a point where edges meet.
+type Vertex struct {
+	Point
+	Edges []int
+}
+
+// Compute the Voronoi diagram of the given sites with Fortune's algorithm in O(n log n). Fails
+// with ErrOpenCell if rounding errors get the better of it, e.g. with sites far closer
+// together than the size of the bounds.
+func NewDiagram(sites []Point, bounds Rect) (*Diagram, error) {
+	s := runSweep(sites, sweepScale(bounds))
+	s.clipEdges(bounds)
+	if err := s.closeCells(bounds); err != nil {
+		return nil, err
+	}
+	s.fillSingleCell(bounds)
+
+	d := &Diagram{
+		Bounds: bounds,
+		Sites:  append([]Point(nil), sites...),
+		Cells:  make([]Cell, len(sites)),
+
+		triangles: s.triangles,
+	}
+
+	edgeIndex := make(map[*edge]int)
+	addVertex := func(v *vertex) int {
+		if v.index < 0 {
+			v.index = len(d.Vertices)
+			d.Vertices = append(d.Vertices, Vertex{Point: v.Point})
+		}
+		return v.index
+	}
+	addEdge := func(e *edge) int {
+		if i, ok := edgeIndex[e]; ok {
+			return i
+		}
+		i := len(d.Edges)
+		edgeIndex[e] = i
+		out := Edge{Left: e.lSite.index, Right: -1, A: addVertex(e.va), B: addVertex(e.vb)}
+		if e.rSite != nil {
+			out.Right = e.rSite.index
+		}
+		d.Edges = append(d.Edges, out)
+		d.Vertices[out.A].Edges = append(d.Vertices[out.A].Edges, i)
+		d.Vertices[out.B].Edges = append(d.Vertices[out.B].Edges, i)
+		return i
+	}
+
+	for i, c := range s.cells {
+		d.Cells[i].Site = i
+		if c == nil {
+			continue
+		}
+		// The sweep orders the halfedges clockwise. Reverse them, and swap their ends, so that
+		// the cells come out counter-clockwise.
+		n := len(c.halfedges)
+		halfedges := make([]HalfEdge, n)
+		for j, h := range c.halfedges {
+			neighbor := -1
+			if h.edge.rSite != nil {
+				if h.edge.lSite == h.site {
+					neighbor = h.edge.rSite.index
+				} else {
+					neighbor = h.edge.lSite.index
+				}
+			}
+			halfedges[n-1-j] = HalfEdge{
+				Edge:     addEdge(h.edge),
+				Start:    addVertex(h.endpoint()),
+				End:      addVertex(h.startpoint()),
+				Neighbor: neighbor,
+			}
+		}
+		d.Cells[i].HalfEdges = halfedges
+	}
+
+	return d, nil
+}
+
+// If no edge crosses the bounds at all (e.g. there is only one site) then one cell covers the
+// whole rectangle. Give it to the site nearest to the centre.
+func (s *sweep) fillSingleCell(bounds Rect) {
+	var best *cell
+	for _, c := range s.cells {
+		if c == nil {
+			continue
+		}
+		if len(c.halfedges) > 0 {
+			return
+		}
+		if best == nil || c.site.Dist(bounds.Center()) < best.site.Dist(bounds.Center()) {
+			best = c
+		}
+	}
+	if best == nil {
+		return
+	}
+
+	// Same orientation as the cells closed by the sweep: clockwise with y pointing up
+	corners := []*vertex{
+		newVertex(bounds.Min.X, bounds.Min.Y),
+		newVertex(bounds.Min.X, bounds.Max.Y),
+		newVertex(bounds.Max.X, bounds.Max.Y),
+		newVertex(bounds.Max.X, bounds.Min.Y),
+	}
+	for i := range corners {
+		e := s.createBorderEdge(best.site, corners[i], corners[(i+1)%len(corners)])
+		best.halfedges = append(best.halfedges, &halfedge{site: best.site, edge: e})
+	}
+}
+
+// Polygon returns the vertices of cell i in counter-clockwise order.
+func (d *Diagram) Polygon(i int) []Point {
+	halfedges := d.Cells[i].HalfEdges
+	polygon := make([]Point, len(halfedges))
+	for j, h := range halfedges {
+		polygon[j] = d.Vertices[h.Start].Point
+	}
+	return polygon
+}
+
+// Polygons returns the polygons of all the cells, in the order of the sites.
+func (d *Diagram) Polygons() [][]Point {
+	polygons := make([][]Point, len(d.Cells))
+	for i := range d.Cells {
+		polygons[i] = d.Polygon(i)
+	}
+	return polygons
+}
+
+// Neighbors returns the indices of the sites whose cells share an edge with this one.
+func (c Cell) Neighbors() []int {
+	neighbors := make([]int, 0, len(c.HalfEdges))
+	for _, h := range c.HalfEdges {
+		if h.Neighbor >= 0 {
+			neighbors = append(neighbors, h.Neighbor)
+		}
+	}
+	return neighbors
+}
+
+// Nearest returns the index of the site closest to p, or -1 if there are no sites.
+func (d *Diagram) Nearest(p Point) int {
+	nearest := -1
+	best := 0.0
+	for i, site := range d.Sites {
+		if dist := site.Dist(p); nearest < 0 || dist < best {
+			nearest = i
+			best = dist
+		}
+	}
+	return nearest
+}
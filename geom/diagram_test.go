@@ -0,0 +1,157 @@
+package geom
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+var unitSquare = R(0, 0, 1, 1)
+
+func randomSites(rng *rand.Rand, n int, bounds Rect) []Point {
+	sites := make([]Point, n)
+	for i := range sites {
+		sites[i] = Pt(bounds.Min.X+rng.Float64()*bounds.Dx(), bounds.Min.Y+rng.Float64()*bounds.Dy())
+	}
+	return sites
+}
+
+// The signed area of the polygon, positive when counter-clockwise, measured from origin so that
+// the coordinates of far away bounds do not cancel out.
+func areaFrom(polygon []Point, origin Point) float64 {
+	area := 0.0
+	for i := range polygon {
+		a, b := polygon[i].Sub(origin), polygon[(i+1)%len(polygon)].Sub(origin)
+		area += a.X*b.Y - b.X*a.Y
+	}
+	return area / 2
+}
+
+// Whether p is inside the counter-clockwise convex polygon, up to tolerance.
+func convexContains(polygon []Point, p Point, tolerance float64) bool {
+	for i, a := range polygon {
+		b := polygon[(i+1)%len(polygon)]
+		edge, to := b.Sub(a), p.Sub(a)
+		if edge.X*to.Y-edge.Y*to.X < -tolerance*math.Hypot(edge.X, edge.Y) {
+			return false
+		}
+	}
+	return true
+}
+
+// Check that the cells tile the bounds, go counter-clockwise and contain their own site. Only
+// one of duplicate sites gets a cell.
+func checkDiagram(t *testing.T, sites []Point, bounds Rect) *Diagram {
+	t.Helper()
+	d, err := NewDiagram(sites, bounds)
+	if err != nil {
+		t.Fatalf("NewDiagram: %v", err)
+	}
+	size := max(bounds.Dx(), bounds.Dy())
+
+	total := 0.0
+	owners := map[Point]int{}
+	for i, site := range sites {
+		polygon := d.Polygon(i)
+		area := areaFrom(polygon, bounds.Min)
+		total += area
+		if area < 0 {
+			t.Errorf("cell %d is clockwise, area %v", i, area)
+		}
+		if len(polygon) == 0 {
+			continue
+		}
+		if j, ok := owners[site]; ok {
+			t.Errorf("sites %d and %d at %v both have a cell", j, i, site)
+		}
+		owners[site] = i
+		if bounds.Contains(site) && !convexContains(polygon, site, 1e-9*size) {
+			t.Errorf("cell %d %v does not contain its site %v", i, polygon, site)
+		}
+	}
+	for _, site := range sites {
+		if _, ok := owners[site]; !ok && bounds.Contains(site) {
+			t.Errorf("no cell contains site %v", site)
+		}
+	}
+	if want := bounds.Dx() * bounds.Dy(); math.Abs(total-want) > 1e-9*want {
+		t.Errorf("the cells cover %v, the bounds %v", total, want)
+	}
+	return d
+}
+
+func TestDiagramRandom(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, n := range []int{1, 2, 3, 10, 100, 1000} {
+		for seed := 0; seed < 5; seed++ {
+			checkDiagram(t, randomSites(rng, n, unitSquare), unitSquare)
+		}
+	}
+}
+
+// Sites packed tightly or spread far apart, around the origin and away from it
+func TestDiagramScales(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	for _, size := range []float64{1e-9, 1e-7, 1e-5, 1e3, 1e8, 1e12} {
+		for _, offset := range []float64{0, -0.5, 3, 1e3} {
+			bounds := R(offset*size, offset*size, (offset+1)*size, (offset+1)*size)
+			for seed := 0; seed < 5; seed++ {
+				checkDiagram(t, randomSites(rng, 100, bounds), bounds)
+			}
+		}
+	}
+}
+
+func TestDiagramGrid(t *testing.T) {
+	for _, k := range []int{2, 3, 10} {
+		var sites []Point
+		for y := 0; y < k; y++ {
+			for x := 0; x < k; x++ {
+				sites = append(sites, Pt((float64(x)+0.5)/float64(k), (float64(y)+0.5)/float64(k)))
+			}
+		}
+		d := checkDiagram(t, sites, unitSquare)
+		for i := range sites {
+			// The cells are the squares around the sites
+			if area := areaFrom(d.Polygon(i), Point{}); math.Abs(area-1/float64(k*k)) > 1e-12 {
+				t.Errorf("%dx%d grid: cell %d has area %v", k, k, i, area)
+			}
+			for _, p := range d.Polygon(i) {
+				if offset := p.Sub(sites[i]); math.Abs(max(math.Abs(offset.X), math.Abs(offset.Y))-0.5/float64(k)) > 1e-12 {
+					t.Errorf("%dx%d grid: cell %d has the corner %v, not on the square around %v", k, k, i, p, sites[i])
+				}
+			}
+		}
+	}
+}
+
+func TestDiagramCollinear(t *testing.T) {
+	lines := map[string]func(f float64) Point{
+		"horizontal": func(f float64) Point { return Pt(f, 0.5) },
+		"vertical":   func(f float64) Point { return Pt(0.5, f) },
+		"diagonal":   func(f float64) Point { return Pt(f, f) },
+		"steep":      func(f float64) Point { return Pt(0.4+f/10, f) },
+	}
+	for name, line := range lines {
+		t.Run(name, func(t *testing.T) {
+			var sites []Point
+			for i := 0; i < 7; i++ {
+				sites = append(sites, line((float64(i)+0.5)/7))
+			}
+			d := checkDiagram(t, sites, unitSquare)
+			for i := range sites {
+				if len(d.Polygon(i)) == 0 {
+					t.Errorf("site %d has no cell", i)
+				}
+			}
+		})
+	}
+}
+
+func TestDiagramDuplicates(t *testing.T) {
+	sites := []Point{{0.2, 0.2}, {0.8, 0.3}, {0.2, 0.2}, {0.5, 0.9}, {0.8, 0.3}, {0.2, 0.2}}
+	checkDiagram(t, sites, unitSquare)
+
+	// Nothing but duplicates: one cell covers everything
+	checkDiagram(t, []Point{{0.3, 0.3}, {0.3, 0.3}, {0.3, 0.3}}, unitSquare)
+}
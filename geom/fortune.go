@@ -0,0 +1,505 @@
+package geom
+
+import (
+	"math"
+	"sort"
+)
+
+// Fortune's sweep-line algorithm. The sweep line moves in the direction of increasing y, the
+// beachline is a red-black tree of parabolic arcs and the circle events are kept in a second
+// red-black tree ordered by the y coordinate at which they fire.
+//
+// The structure follows Raymond Hill's javascript implementation
+// (https://github.com/gorhill/Javascript-Voronoi), including its epsilon handling. Its
+// tolerances assume coordinates of about 1, so here they are scaled with the size of the
+// diagram, see sweepScale.
+
+// Tolerance of the comparisons of the sweep for a diagram of size 1
+const epsilon = 1e-9
+
+// The size the tolerances of the sweep are scaled with for a diagram over r: the size of r, but
+// not so small that the tolerance drops below the rounding error of its coordinates.
+func sweepScale(r Rect) float64 {
+	size := max(r.Dx(), r.Dy())
+	magnitude := max(math.Abs(r.Min.X), math.Abs(r.Min.Y), math.Abs(r.Max.X), math.Abs(r.Max.Y))
+	scale := max(size, 1e-4*magnitude)
+	if scale == 0 || math.IsInf(scale, 0) || math.IsNaN(scale) {
+		return 1
+	}
+	return scale
+}
+
+func (s *sweep) equalWithEpsilon(a, b float64) bool {
+	return math.Abs(a-b) < s.epsilon
+}
+
+func (s *sweep) greaterThanWithEpsilon(a, b float64) bool {
+	return a-b > s.epsilon
+}
+
+func (s *sweep) lessThanWithEpsilon(a, b float64) bool {
+	return b-a > s.epsilon
+}
+
+type site struct {
+	Point
+	index int
+}
+
+type vertex struct {
+	Point
+	index int // index in Diagram.Vertices. Only valid once the diagram is built.
+}
+
+type edge struct {
+	lSite, rSite *site // rSite is nil for edges along the bounding rectangle
+	va, vb       *vertex
+}
+
+type halfedge struct {
+	site  *site
+	edge  *edge
+	angle float64
+}
+
+func (h *halfedge) startpoint() *vertex {
+	if h.edge.lSite == h.site {
+		return h.edge.va
+	}
+	return h.edge.vb
+}
+
+func (h *halfedge) endpoint() *vertex {
+	if h.edge.lSite == h.site {
+		return h.edge.vb
+	}
+	return h.edge.va
+}
+
+type cell struct {
+	site      *site
+	halfedges []*halfedge
+	closeMe   bool
+}
+
+type arc struct {
+	site   *site
+	circle *rbNode[circleEvent]
+	edge   *edge
+}
+
+type circleEvent struct {
+	arc     *rbNode[arc]
+	site    *site
+	x, y    float64
+	ycenter float64
+}
+
+type sweep struct {
+	// The size of the diagram, and the tolerance of the comparisons scaled with it
+	scale, epsilon float64
+
+	beachline   rbTree[arc]
+	circles     rbTree[circleEvent]
+	firstCircle *rbNode[circleEvent]
+
+	cells []*cell // indexed by site index. nil for duplicate sites.
+	edges []*edge
+
+	// Every vertex created by the sweep is the circumcentre of a Delaunay triangle. The sites
+	// of these triangles are recorded here, three site indices per triangle.
+	triangles []int
+}
+
+// Run the sweep over the given sites, with the tolerances scaled to a diagram of the given
+// size. The returned sweep holds the unclipped edges.
+func runSweep(sites []Point, scale float64) *sweep {
+	s := &sweep{scale: scale, epsilon: epsilon * scale, cells: make([]*cell, len(sites))}
+
+	queue := make([]*site, len(sites))
+	for i, p := range sites {
+		queue[i] = &site{p, i}
+	}
+	// Sort in reverse so that popping from the end yields the lowest y (then lowest x) first
+	sort.SliceStable(queue, func(i, j int) bool {
+		a, b := queue[i], queue[j]
+		if a.Y != b.Y {
+			return a.Y > b.Y
+		}
+		return a.X > b.X
+	})
+
+	pop := func() *site {
+		if len(queue) == 0 {
+			return nil
+		}
+		next := queue[len(queue)-1]
+		queue = queue[:len(queue)-1]
+		return next
+	}
+
+	next := pop()
+	var prev *site
+	for {
+		circle := s.firstCircle
+		if next != nil && (circle == nil || next.Y < circle.value.y || (next.Y == circle.value.y && next.X < circle.value.x)) {
+			// Skip duplicate sites
+			if prev == nil || next.X != prev.X || next.Y != prev.Y {
+				s.cells[next.index] = &cell{site: next}
+				s.addBeachsection(next)
+				prev = next
+			}
+			next = pop()
+		} else if circle != nil {
+			s.removeBeachsection(circle.value.arc)
+		} else {
+			break
+		}
+	}
+
+	return s
+}
+
+func (s *sweep) createEdge(lSite, rSite *site, va, vb *vertex) *edge {
+	e := &edge{lSite: lSite, rSite: rSite}
+	s.edges = append(s.edges, e)
+	if va != nil {
+		setEdgeStartpoint(e, lSite, rSite, va)
+	}
+	if vb != nil {
+		setEdgeEndpoint(e, lSite, rSite, vb)
+	}
+	lCell := s.cells[lSite.index]
+	rCell := s.cells[rSite.index]
+	lCell.halfedges = append(lCell.halfedges, newHalfedge(e, lSite, rSite))
+	rCell.halfedges = append(rCell.halfedges, newHalfedge(e, rSite, lSite))
+	return e
+}
+
+func (s *sweep) createBorderEdge(lSite *site, va, vb *vertex) *edge {
+	e := &edge{lSite: lSite, va: va, vb: vb}
+	s.edges = append(s.edges, e)
+	return e
+}
+
+func newHalfedge(e *edge, lSite, rSite *site) *halfedge {
+	h := &halfedge{site: lSite, edge: e}
+	// The angle is used to sort the halfedges of a cell. For edges between two sites we use the
+	// angle of the line joining the sites since the edge itself might not have both endpoints
+	// yet. Border edges always have both endpoints.
+	if rSite != nil {
+		h.angle = math.Atan2(rSite.Y-lSite.Y, rSite.X-lSite.X)
+	} else {
+		va, vb := e.va, e.vb
+		if e.lSite == lSite {
+			h.angle = math.Atan2(vb.X-va.X, va.Y-vb.Y)
+		} else {
+			h.angle = math.Atan2(va.X-vb.X, vb.Y-va.Y)
+		}
+	}
+	return h
+}
+
+func setEdgeStartpoint(e *edge, lSite, rSite *site, v *vertex) {
+	if e.va == nil && e.vb == nil {
+		e.va = v
+		e.lSite = lSite
+		e.rSite = rSite
+	} else if e.lSite == rSite {
+		e.vb = v
+	} else {
+		e.va = v
+	}
+}
+
+func setEdgeEndpoint(e *edge, lSite, rSite *site, v *vertex) {
+	setEdgeStartpoint(e, rSite, lSite, v)
+}
+
+func (s *sweep) addTriangle(a, b, c *site) {
+	if a == b || b == c || c == a {
+		return
+	}
+	s.triangles = append(s.triangles, a.index, b.index, c.index)
+}
+
+func newVertex(x, y float64) *vertex {
+	return &vertex{Point{x, y}, -1}
+}
+
+// Calculate the left breakpoint of a particular beach section, given a particular sweep line.
+func leftBreakPoint(node *rbNode[arc], directrix float64) float64 {
+	// For the derivation see http://en.wikipedia.org/wiki/Parabola and Raymond Hill's notes.
+	rfocx := node.value.site.X
+	rfocy := node.value.site.Y
+	pby2 := rfocy - directrix
+	// Parabola in degenerate case where focus is on directrix
+	if pby2 == 0 {
+		return rfocx
+	}
+	lArc := node.prev
+	if lArc == nil {
+		return math.Inf(-1)
+	}
+	lfocx := lArc.value.site.X
+	lfocy := lArc.value.site.Y
+	plby2 := lfocy - directrix
+	// Parabola in degenerate case where focus is on directrix
+	if plby2 == 0 {
+		return lfocx
+	}
+	hl := lfocx - rfocx
+	aby2 := 1/pby2 - 1/plby2
+	b := hl / plby2
+	if aby2 != 0 {
+		return (-b+math.Sqrt(b*b-2*aby2*(hl*hl/(-2*plby2)-lfocy+plby2/2+rfocy-pby2/2)))/aby2 + rfocx
+	}
+	// Both parabolas have the same distance to the directrix, so the breakpoint is midway
+	return (rfocx + lfocx) / 2
+}
+
+// Calculate the right breakpoint of a particular beach section, given a particular sweep line.
+func rightBreakPoint(node *rbNode[arc], directrix float64) float64 {
+	if rArc := node.next; rArc != nil {
+		return leftBreakPoint(rArc, directrix)
+	}
+	if node.value.site.Y == directrix {
+		return node.value.site.X
+	}
+	return math.Inf(1)
+}
+
+func (s *sweep) detachBeachsection(node *rbNode[arc]) {
+	s.detachCircleEvent(node)
+	s.beachline.remove(node)
+}
+
+func (s *sweep) removeBeachsection(node *rbNode[arc]) {
+	circle := node.value.circle.value
+	x := circle.x
+	y := circle.ycenter
+	v := newVertex(x, y)
+	previous := node.prev
+	next := node.next
+	disappearing := []*rbNode[arc]{node}
+
+	s.detachBeachsection(node)
+
+	// Look left for other arcs collapsing at the same point
+	lArc := previous
+	for lArc.value.circle != nil &&
+		math.Abs(x-lArc.value.circle.value.x) < s.epsilon &&
+		math.Abs(y-lArc.value.circle.value.ycenter) < s.epsilon {
+		previous = lArc.prev
+		disappearing = append([]*rbNode[arc]{lArc}, disappearing...)
+		s.detachBeachsection(lArc)
+		lArc = previous
+	}
+	// The left-most arc stays on the beachline but gets a new edge
+	disappearing = append([]*rbNode[arc]{lArc}, disappearing...)
+	s.detachCircleEvent(lArc)
+
+	// Look right for other arcs collapsing at the same point
+	rArc := next
+	for rArc.value.circle != nil &&
+		math.Abs(x-rArc.value.circle.value.x) < s.epsilon &&
+		math.Abs(y-rArc.value.circle.value.ycenter) < s.epsilon {
+		next = rArc.next
+		disappearing = append(disappearing, rArc)
+		s.detachBeachsection(rArc)
+		rArc = next
+	}
+	disappearing = append(disappearing, rArc)
+	s.detachCircleEvent(rArc)
+
+	// Close the edges between each pair of consecutive disappearing arcs at the new vertex
+	for i := 1; i < len(disappearing); i++ {
+		rArc = disappearing[i]
+		lArc = disappearing[i-1]
+		setEdgeStartpoint(rArc.value.edge, lArc.value.site, rArc.value.site, v)
+	}
+
+	// All the sites of the disappearing arcs lie on a circle around the new vertex, in order
+	// along the beachline. Fan them into triangles.
+	for i := 1; i < len(disappearing)-1; i++ {
+		s.addTriangle(disappearing[0].value.site, disappearing[i].value.site, disappearing[i+1].value.site)
+	}
+
+	// Create a new edge between the two arcs which now neighbour each other
+	lArc = disappearing[0]
+	rArc = disappearing[len(disappearing)-1]
+	rArc.value.edge = s.createEdge(lArc.value.site, rArc.value.site, nil, v)
+
+	s.attachCircleEvent(lArc)
+	s.attachCircleEvent(rArc)
+}
+
+func (s *sweep) addBeachsection(st *site) {
+	x := st.X
+	directrix := st.Y
+
+	// Find the arc(s) directly above the new site
+	var lArc, rArc *rbNode[arc]
+	node := s.beachline.root
+	for node != nil {
+		dxl := leftBreakPoint(node, directrix) - x
+		if dxl > s.epsilon {
+			// x is left of the left breakpoint
+			node = node.left
+		} else {
+			dxr := x - rightBreakPoint(node, directrix)
+			if dxr > s.epsilon {
+				// x is right of the right breakpoint
+				if node.right == nil {
+					lArc = node
+					break
+				}
+				node = node.right
+			} else {
+				if dxl > -s.epsilon {
+					// x falls exactly on the left breakpoint
+					lArc = node.prev
+					rArc = node
+				} else if dxr > -s.epsilon {
+					// x falls exactly on the right breakpoint
+					lArc = node
+					rArc = node.next
+				} else {
+					// x falls somewhere in the middle of the arc
+					lArc = node
+					rArc = node
+				}
+				break
+			}
+		}
+	}
+
+	newArc := &rbNode[arc]{value: arc{site: st}}
+	s.beachline.insertSuccessor(lArc, newArc)
+
+	// The first arc on the beachline
+	if lArc == nil && rArc == nil {
+		return
+	}
+
+	// The new site splits an existing arc in two
+	if lArc == rArc {
+		s.detachCircleEvent(lArc)
+		rArc = &rbNode[arc]{value: arc{site: lArc.value.site}}
+		s.beachline.insertSuccessor(newArc, rArc)
+		e := s.createEdge(lArc.value.site, newArc.value.site, nil, nil)
+		newArc.value.edge = e
+		rArc.value.edge = e
+		s.attachCircleEvent(lArc)
+		s.attachCircleEvent(rArc)
+		return
+	}
+
+	// The new site is the right-most so far. This only happens for sites sharing the lowest y.
+	if lArc != nil && rArc == nil {
+		newArc.value.edge = s.createEdge(lArc.value.site, newArc.value.site, nil, nil)
+		return
+	}
+
+	// The new site falls exactly on a breakpoint. The breakpoint becomes a vertex.
+	s.detachCircleEvent(lArc)
+	s.detachCircleEvent(rArc)
+
+	lSite := lArc.value.site
+	ax, ay := lSite.X, lSite.Y
+	bx, by := st.X-ax, st.Y-ay
+	rSite := rArc.value.site
+	cx, cy := rSite.X-ax, rSite.Y-ay
+	d := 2 * (bx*cy - by*cx)
+	hb := bx*bx + by*by
+	hc := cx*cx + cy*cy
+	v := newVertex((cy*hb-by*hc)/d+ax, (bx*hc-cx*hb)/d+ay)
+
+	s.addTriangle(lSite, st, rSite)
+
+	setEdgeStartpoint(rArc.value.edge, lSite, rSite, v)
+	newArc.value.edge = s.createEdge(lSite, st, nil, v)
+	rArc.value.edge = s.createEdge(st, rSite, nil, v)
+
+	s.attachCircleEvent(lArc)
+	s.attachCircleEvent(rArc)
+}
+
+func (s *sweep) attachCircleEvent(node *rbNode[arc]) {
+	lArc := node.prev
+	rArc := node.next
+	if lArc == nil || rArc == nil {
+		return
+	}
+	lSite := lArc.value.site
+	cSite := node.value.site
+	rSite := rArc.value.site
+
+	// If the left and right sites are the same the breakpoints diverge
+	if lSite == rSite {
+		return
+	}
+
+	bx, by := cSite.X, cSite.Y
+	ax, ay := lSite.X-bx, lSite.Y-by
+	cx, cy := rSite.X-bx, rSite.Y-by
+
+	// Only converging breakpoints produce a circle event, which is when the sites turn clockwise
+	d := 2 * (ax*cy - ay*cx)
+	// The threshold is an area, so it scales with the square of the size
+	if d >= -2e-12*s.scale*s.scale {
+		return
+	}
+
+	ha := ax*ax + ay*ay
+	hc := cx*cx + cy*cy
+	x := (cy*ha - ay*hc) / d
+	y := (ax*hc - cx*ha) / d
+	ycenter := y + by
+
+	event := &rbNode[circleEvent]{value: circleEvent{
+		arc:     node,
+		site:    cSite,
+		x:       x + bx,
+		y:       ycenter + math.Sqrt(x*x+y*y),
+		ycenter: ycenter,
+	}}
+	node.value.circle = event
+
+	// Find the insertion point in the event queue
+	var predecessor *rbNode[circleEvent]
+	n := s.circles.root
+	for n != nil {
+		if event.value.y < n.value.y || (event.value.y == n.value.y && event.value.x <= n.value.x) {
+			if n.left != nil {
+				n = n.left
+			} else {
+				predecessor = n.prev
+				break
+			}
+		} else {
+			if n.right != nil {
+				n = n.right
+			} else {
+				predecessor = n
+				break
+			}
+		}
+	}
+	s.circles.insertSuccessor(predecessor, event)
+	if predecessor == nil {
+		s.firstCircle = event
+	}
+}
+
+func (s *sweep) detachCircleEvent(node *rbNode[arc]) {
+	event := node.value.circle
+	if event == nil {
+		return
+	}
+	if event.prev == nil {
+		s.firstCircle = event.next
+	}
+	s.circles.remove(event)
+	node.value.circle = nil
+}
@@ -0,0 +1,75 @@
+// Package geom contains the CPU side of the Voronoi computation. It has no dependency on
+// OpenGL so it can be used from tools and tests which do not have a window.
+package geom
+
+import "math"
+
+// Point is a 2D point (or vector).
+type Point struct {
+	X, Y float64
+}
+
+// Pt is shorthand for Point{x, y}.
+func Pt(x, y float64) Point {
+	return Point{x, y}
+}
+
+func (p Point) Add(q Point) Point {
+	return Point{p.X + q.X, p.Y + q.Y}
+}
+
+func (p Point) Sub(q Point) Point {
+	return Point{p.X - q.X, p.Y - q.Y}
+}
+
+func (p Point) Mul(k float64) Point {
+	return Point{p.X * k, p.Y * k}
+}
+
+// Dist returns the Euclidean distance between p and q.
+func (p Point) Dist(q Point) float64 {
+	return math.Hypot(p.X-q.X, p.Y-q.Y)
+}
+
+// Rect is an axis-aligned rectangle. Min is the lower-left and Max the upper-right corner.
+type Rect struct {
+	Min, Max Point
+}
+
+// R is shorthand for Rect{Pt(x0, y0), Pt(x1, y1)}. The corners are sorted so that Min <= Max.
+func R(x0, y0, x1, y1 float64) Rect {
+	if x0 > x1 {
+		x0, x1 = x1, x0
+	}
+	if y0 > y1 {
+		y0, y1 = y1, y0
+	}
+	return Rect{Point{x0, y0}, Point{x1, y1}}
+}
+
+func (r Rect) Dx() float64 {
+	return r.Max.X - r.Min.X
+}
+
+func (r Rect) Dy() float64 {
+	return r.Max.Y - r.Min.Y
+}
+
+func (r Rect) Center() Point {
+	return Point{(r.Min.X + r.Max.X) / 2, (r.Min.Y + r.Max.Y) / 2}
+}
+
+// Contains reports whether p lies inside r (boundary included).
+func (r Rect) Contains(p Point) bool {
+	return p.X >= r.Min.X && p.X <= r.Max.X && p.Y >= r.Min.Y && p.Y <= r.Max.Y
+}
+
+// Empty reports whether r has no area.
+func (r Rect) Empty() bool {
+	return r.Min.X >= r.Max.X || r.Min.Y >= r.Max.Y
+}
+
+// Polygon returns the corners of r in counter-clockwise order.
+func (r Rect) Polygon() []Point {
+	return []Point{r.Min, {r.Max.X, r.Min.Y}, r.Max, {r.Min.X, r.Max.Y}}
+}
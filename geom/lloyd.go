@@ -0,0 +1,72 @@
+package geom
+
+// Lloyd relaxation moves every site to the centroid of its cell. Repeating this converges to a
+// centroidal Voronoi tessellation, where the sites are evenly spread (blue noise).
+
+const (
+	DefaultLloydIterations = 100
+	DefaultLloydTolerance  = 1e-6
+)
+
+type LloydOptions struct {
+	// Maximum number of iterations. Zero means DefaultLloydIterations.
+	MaxIterations int
+	// Stop once no site moves further than this in one iteration. Zero means
+	// DefaultLloydTolerance.
+	Tolerance float64
+
+	// The metric of the cells, and its p parameter. See Cells.
+	Metric Metric
+	P      float64
+
+	// Optional per-site weights, 0 for the sites beyond the end of Weights. See WeightedCells.
+	Weighting Weighting
+	Weights   []float64
+}
+
+// LloydStep performs a single iteration of Lloyd relaxation and returns the new sites together
+// with the largest (Euclidean) distance any site moved. Sites without a cell stay where they
+// are. Only the metric and weights of the options are used. Fails if the cells do, see
+// WeightedCells.
+func LloydStep(sites []Point, bounds Rect, options LloydOptions) ([]Point, float64, error) {
+	cells, err := WeightedCells(sites, options.Weights, bounds, options.Weighting, options.Metric, options.P)
+	if err != nil {
+		return nil, 0, err
+	}
+	relaxed := make([]Point, len(sites))
+	moved := 0.0
+	for i, site := range sites {
+		relaxed[i] = site
+		if len(cells[i]) == 0 {
+			continue
+		}
+		relaxed[i] = Centroid(cells[i])
+		moved = max(moved, site.Dist(relaxed[i]))
+	}
+	return relaxed, moved, nil
+}
+
+// Relax runs Lloyd relaxation until the sites move less than the tolerance or the iteration
+// limit is reached. Returns the relaxed sites and the number of iterations performed. Fails if
+// an iteration does, see LloydStep.
+func Relax(sites []Point, bounds Rect, options LloydOptions) ([]Point, int, error) {
+	if options.MaxIterations <= 0 {
+		options.MaxIterations = DefaultLloydIterations
+	}
+	if options.Tolerance <= 0 {
+		options.Tolerance = DefaultLloydTolerance
+	}
+
+	relaxed := append([]Point(nil), sites...)
+	for i := 0; i < options.MaxIterations; i++ {
+		next, moved, err := LloydStep(relaxed, bounds, options)
+		if err != nil {
+			return nil, i, err
+		}
+		relaxed = next
+		if moved < options.Tolerance {
+			return relaxed, i + 1, nil
+		}
+	}
+	return relaxed, options.MaxIterations, nil
+}
@@ -0,0 +1,33 @@
+package geom
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestRelaxConverges(t *testing.T) {
+	rng := rand.New(rand.NewSource(4))
+	sites := randomSites(rng, 30, unitSquare)
+	options := LloydOptions{MaxIterations: 1000, Tolerance: 1e-5}
+	relaxed, iterations, err := Relax(sites, unitSquare, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if iterations >= options.MaxIterations {
+		t.Fatalf("Relax did not converge in %d iterations", iterations)
+	}
+	_, moved, err := LloydStep(relaxed, unitSquare, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if moved >= options.Tolerance {
+		t.Errorf("after convergence the sites still move by %v", moved)
+	}
+
+	// The relaxation slows down as it goes
+	first, firstMoved, _ := LloydStep(sites, unitSquare, options)
+	_, secondMoved, _ := LloydStep(first, unitSquare, options)
+	if secondMoved > firstMoved {
+		t.Errorf("the second step moves the sites by %v, more than the first by %v", secondMoved, firstMoved)
+	}
+}
@@ -0,0 +1,184 @@
+package geom
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Metric selects the distance function used to decide which site is closest. The values are
+// shared with the shaders, so they must not be reordered.
+type Metric int
+
+const (
+	Euclidean Metric = iota // L2
+	Manhattan               // L1
+	Chebyshev               // L∞
+	Minkowski               // Lp, with the p parameter
+	NumMetrics
+)
+
+// DefaultMinkowskiP is a p which looks clearly different from both L1 and L2.
+const DefaultMinkowskiP = 3.0
+
+func (m Metric) String() string {
+	switch m {
+	case Euclidean:
+		return "L2"
+	case Manhattan:
+		return "L1"
+	case Chebyshev:
+		return "L∞"
+	case Minkowski:
+		return "Lp"
+	}
+	return fmt.Sprintf("Metric(%d)", int(m))
+}
+
+// ParseMetric returns the metric with the given name, either as printed by String or spelled
+// out (e.g. "euclidean" or "minkowski"), ignoring case.
+func ParseMetric(name string) (Metric, error) {
+	for m := Metric(0); m < NumMetrics; m++ {
+		if strings.EqualFold(name, m.String()) || strings.EqualFold(name, metricNames[m]) {
+			return m, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown metric %q", name)
+}
+
+var metricNames = [NumMetrics]string{"euclidean", "manhattan", "chebyshev", "minkowski"}
+
+// Next returns the metric after m, wrapping around. Used to cycle through the metrics.
+func (m Metric) Next() Metric {
+	return (m + 1) % NumMetrics
+}
+
+// Distance returns the distance between a and b. p is only used by Minkowski, and is clamped
+// to at least 1 so that the distance stays a metric.
+func (m Metric) Distance(a, b Point, p float64) float64 {
+	dx := math.Abs(a.X - b.X)
+	dy := math.Abs(a.Y - b.Y)
+	switch m {
+	case Manhattan:
+		return dx + dy
+	case Chebyshev:
+		return max(dx, dy)
+	case Minkowski:
+		p = max(p, 1)
+		if math.IsInf(p, 1) {
+			return max(dx, dy)
+		}
+		return math.Pow(math.Pow(dx, p)+math.Pow(dy, p), 1/p)
+	}
+	return math.Hypot(dx, dy)
+}
+
+// Number of rays cast from each site by Cells for the non-Euclidean metrics.
+const metricCellRays = 360
+
+// Cells returns the polygon of the cell of every site under the given metric, clipped to
+// bounds. Euclidean cells are exact (see NewDiagram). The bisectors of the other metrics are
+// not straight lines, so those cells are approximated by casting rays from each site and
+// finding where they leave the cell. This works because the cells of any norm are star-shaped
+// around their site. It is O(n² log n) so it is meant for hundreds of sites, not thousands.
+// Only the Euclidean cells can fail, see NewDiagram.
+func Cells(sites []Point, bounds Rect, metric Metric, p float64) ([][]Point, error) {
+	if metric == Euclidean {
+		d, err := NewDiagram(sites, bounds)
+		if err != nil {
+			return nil, err
+		}
+		return d.Polygons(), nil
+	}
+
+	cells := make([][]Point, len(sites))
+	for i := range sites {
+		cells[i] = starCell(sites, i, bounds, func(q Point, j int) float64 {
+			return metric.Distance(q, sites[j], p)
+		})
+	}
+	return cells, nil
+}
+
+// Approximate the cell of site i by casting rays from it. dist(q, j) is the distance of q to
+// site j. Along a ray from site i the difference dist(q, j) - dist(q, i) must not increase,
+// which holds for any norm, also with additive weights.
+func starCell(sites []Point, i int, bounds Rect, dist func(q Point, j int) float64) []Point {
+	s := sites[i]
+	if !bounds.Contains(s) {
+		return nil
+	}
+	// If another site is at least as close to s as s itself, the cell is empty
+	for j := range sites {
+		if j != i && dist(s, j) < dist(s, i) {
+			return nil
+		}
+		if j < i && sites[j] == s && dist(s, j) == dist(s, i) {
+			return nil
+		}
+	}
+
+	// Visit the other sites nearest first so that far away ones can be skipped
+	others := make([]int, 0, len(sites)-1)
+	reach := make([]float64, len(sites))
+	for j := range sites {
+		if j != i {
+			others = append(others, j)
+			reach[j] = (dist(s, j) + dist(s, i)) / 2
+		}
+	}
+	sort.Slice(others, func(a, b int) bool {
+		return reach[others[a]] < reach[others[b]]
+	})
+
+	tolerance := 1e-7 * max(bounds.Dx(), bounds.Dy())
+	polygon := make([]Point, 0, metricCellRays)
+	for k := 0; k < metricCellRays; k++ {
+		angle := 2 * math.Pi * float64(k) / metricCellRays
+		d := Point{math.Cos(angle), math.Sin(angle)}
+		along := func(t float64) Point { return s.Add(d.Mul(t)) }
+
+		// Start with the point where the ray leaves the bounds
+		t := rayExit(s, d, bounds)
+		for _, j := range others {
+			// By the triangle inequality a site can only claim points which are at least half
+			// its distance away from s. The weights (if any) shift this by their average.
+			if reach[j] > dist(along(t), i) {
+				break
+			}
+			if dist(along(t), j) > dist(along(t), i) {
+				continue
+			}
+			// Bisect for the boundary between the two cells
+			lo, hi := 0.0, t
+			for hi-lo > tolerance {
+				mid := (lo + hi) / 2
+				if dist(along(mid), j) > dist(along(mid), i) {
+					lo = mid
+				} else {
+					hi = mid
+				}
+			}
+			t = hi
+		}
+		polygon = append(polygon, along(t))
+	}
+	return polygon
+}
+
+// Distance from p (inside r) along direction d to the boundary of r.
+func rayExit(p, d Point, r Rect) float64 {
+	t := math.Inf(1)
+	if d.X > 0 {
+		t = min(t, (r.Max.X-p.X)/d.X)
+	} else if d.X < 0 {
+		t = min(t, (r.Min.X-p.X)/d.X)
+	}
+	if d.Y > 0 {
+		t = min(t, (r.Max.Y-p.Y)/d.Y)
+	} else if d.Y < 0 {
+		t = min(t, (r.Min.Y-p.Y)/d.Y)
+	}
+	return t
+}
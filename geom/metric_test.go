@@ -0,0 +1,26 @@
+package geom
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestCellsMetrics(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	sites := randomSites(rng, 20, unitSquare)
+	for metric := Metric(0); metric < NumMetrics; metric++ {
+		cells, err := Cells(sites, unitSquare, metric, 3)
+		if err != nil {
+			t.Fatalf("%v: %v", metric, err)
+		}
+		total := 0.0
+		for _, cell := range cells {
+			total += Area(cell)
+		}
+		// The rays only approximate the curved and kinked edges
+		if math.Abs(total-1) > 0.01 {
+			t.Errorf("%v: the cells cover %v of the unit square", metric, total)
+		}
+	}
+}
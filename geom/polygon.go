@@ -0,0 +1,36 @@
+package geom
+
+// Area returns the signed area of a polygon. It is positive for counter-clockwise polygons.
+func Area(polygon []Point) float64 {
+	area := 0.0
+	for i, p := range polygon {
+		q := polygon[(i+1)%len(polygon)]
+		area += p.X*q.Y - q.X*p.Y
+	}
+	return area / 2
+}
+
+// Centroid returns the centre of mass of a polygon. Degenerate polygons (with no area) return
+// the average of their vertices instead.
+func Centroid(polygon []Point) Point {
+	if len(polygon) == 0 {
+		return Point{}
+	}
+
+	var cx, cy, area float64
+	for i, p := range polygon {
+		q := polygon[(i+1)%len(polygon)]
+		cross := p.X*q.Y - q.X*p.Y
+		area += cross
+		cx += (p.X + q.X) * cross
+		cy += (p.Y + q.Y) * cross
+	}
+	if area == 0 {
+		var mean Point
+		for _, p := range polygon {
+			mean = mean.Add(p)
+		}
+		return mean.Mul(1 / float64(len(polygon)))
+	}
+	return Point{cx / (3 * area), cy / (3 * area)}
+}
@@ -0,0 +1,144 @@
+package geom
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Weighting selects how per-site weights change the distance to a site. The values are shared
+// with the shaders, so they must not be reordered.
+type Weighting int
+
+const (
+	// Weights are ignored
+	Unweighted Weighting = iota
+	// Power diagram (Laguerre-Voronoi): the distance to site s is |q - s|² - w. The cells are
+	// convex polygons, but a site is not necessarily inside its own cell.
+	Power
+	// Additively weighted (Apollonius) diagram: the distance to site s is d(q, s) - w. The
+	// edges are hyperbolic arcs.
+	Additive
+	NumWeightings
+)
+
+func (w Weighting) String() string {
+	switch w {
+	case Unweighted:
+		return "unweighted"
+	case Power:
+		return "power"
+	case Additive:
+		return "additive"
+	}
+	return fmt.Sprintf("Weighting(%d)", int(w))
+}
+
+// ParseWeighting returns the weighting with the given name, as printed by String, ignoring case.
+func ParseWeighting(name string) (Weighting, error) {
+	for w := Weighting(0); w < NumWeightings; w++ {
+		if strings.EqualFold(name, w.String()) {
+			return w, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown weighting %q", name)
+}
+
+// Next returns the weighting after w, wrapping around. Used to cycle through the weightings.
+func (w Weighting) Next() Weighting {
+	return (w + 1) % NumWeightings
+}
+
+// WeightedCells returns the polygon of the cell of every site, with the given weighting. Sites
+// with an empty cell get a nil polygon. The metric is used by the unweighted and additive
+// cells; power cells are always Euclidean. Sites without a weight, beyond the end of weights,
+// have a weight of 0. Only the unweighted cells can fail, see Cells.
+func WeightedCells(sites []Point, weights []float64, bounds Rect, weighting Weighting, metric Metric, p float64) ([][]Point, error) {
+	switch weighting {
+	case Power:
+		return PowerCells(sites, weights, bounds), nil
+	case Additive:
+		return AdditiveCells(sites, weights, bounds, metric, p), nil
+	}
+	return Cells(sites, bounds, metric, p)
+}
+
+// PowerCells returns the cells of the power diagram of the sites, clipped to bounds. Every cell
+// is the intersection of half-planes, one per other site, which makes this O(n²).
+// Missing weights are 0, as with WeightedCells.
+func PowerCells(sites []Point, weights []float64, bounds Rect) [][]Point {
+	weights = padWeights(weights, len(sites))
+	cells := make([][]Point, len(sites))
+	for i, s := range sites {
+		cell := bounds.Polygon()
+		for j, t := range sites {
+			if j == i {
+				continue
+			}
+			if t == s {
+				// Coincident sites: the larger weight wins, ties go to the first one
+				if weights[j] > weights[i] || (weights[j] == weights[i] && j < i) {
+					cell = nil
+					break
+				}
+				continue
+			}
+			// |q - s|² - ws <= |q - t|² - wt  <=>  2 q·(t - s) <= |t|² - |s|² - wt + ws
+			normal := t.Sub(s).Mul(2)
+			offset := t.X*t.X + t.Y*t.Y - s.X*s.X - s.Y*s.Y - weights[j] + weights[i]
+			cell = clipHalfPlane(cell, normal, offset)
+			if len(cell) == 0 {
+				break
+			}
+		}
+		if len(cell) > 0 {
+			cells[i] = cell
+		}
+	}
+	return cells
+}
+
+// AdditiveCells returns the cells of the additively weighted (Apollonius) diagram of the sites,
+// clipped to bounds. Like the non-Euclidean cells of Cells, they are approximated by casting
+// rays from each site. Missing weights are 0, as with WeightedCells.
+func AdditiveCells(sites []Point, weights []float64, bounds Rect, metric Metric, p float64) [][]Point {
+	weights = padWeights(weights, len(sites))
+	cells := make([][]Point, len(sites))
+	for i := range sites {
+		cells[i] = starCell(sites, i, bounds, func(q Point, j int) float64 {
+			return metric.Distance(q, sites[j], p) - weights[j]
+		})
+	}
+	return cells
+}
+
+// The weights of n sites: the given ones, and 0 for the sites beyond them.
+func padWeights(weights []float64, n int) []float64 {
+	if len(weights) >= n {
+		return weights
+	}
+	padded := make([]float64, n)
+	copy(padded, weights)
+	return padded
+}
+
+// Clip a convex polygon to the half-plane normal·q <= offset (Sutherland-Hodgman).
+func clipHalfPlane(polygon []Point, normal Point, offset float64) []Point {
+	inside := func(q Point) float64 {
+		return offset - (normal.X*q.X + normal.Y*q.Y)
+	}
+	clipped := make([]Point, 0, len(polygon)+1)
+	for k, a := range polygon {
+		b := polygon[(k+1)%len(polygon)]
+		da, db := inside(a), inside(b)
+		if da >= 0 {
+			clipped = append(clipped, a)
+		}
+		if (da >= 0) != (db >= 0) {
+			clipped = append(clipped, a.Add(b.Sub(a).Mul(da/(da-db))))
+		}
+	}
+	if len(clipped) < 3 {
+		return nil
+	}
+	return clipped
+}
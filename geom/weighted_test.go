@@ -0,0 +1,53 @@
+package geom
+
+import (
+	"math"
+	"testing"
+)
+
+func TestWeightedCells(t *testing.T) {
+	sites := []Point{{0.2, 0.2}, {0.8, 0.3}, {0.5, 0.9}}
+	for weighting := Weighting(0); weighting < NumWeightings; weighting++ {
+		var areas []float64
+		for _, weights := range [][]float64{{0, 0, 0}, {0.05, 0, 0}} {
+			cells, err := WeightedCells(sites, weights, unitSquare, weighting, Euclidean, 2)
+			if err != nil {
+				t.Fatalf("%v with weights %v: %v", weighting, weights, err)
+			}
+			total := 0.0
+			for i, cell := range cells {
+				if len(cell) == 0 {
+					t.Errorf("%v with weights %v: site %d has no cell", weighting, weights, i)
+				}
+				if i == 0 {
+					areas = append(areas, Area(cell))
+				}
+				total += Area(cell)
+			}
+			if math.Abs(total-1) > 0.01 {
+				t.Errorf("%v with weights %v: the cells cover %v of the unit square", weighting, weights, total)
+			}
+		}
+		// A weight grows the cell, unless the weights are ignored
+		if grew := areas[1] > areas[0]; grew != (weighting != Unweighted) {
+			t.Errorf("%v: the weight of site 0 changes the area of its cell from %v to %v", weighting, areas[0], areas[1])
+		}
+	}
+}
+
+func TestWeightedCellsMissingWeights(t *testing.T) {
+	sites := []Point{{0.2, 0.2}, {0.8, 0.3}, {0.5, 0.9}}
+	for weighting := Weighting(0); weighting < NumWeightings; weighting++ {
+		for _, weights := range [][]float64{nil, {0.1}} {
+			cells, err := WeightedCells(sites, weights, unitSquare, weighting, Euclidean, 2)
+			if err != nil {
+				t.Fatalf("%v with weights %v: %v", weighting, weights, err)
+			}
+			for i, cell := range cells {
+				if len(cell) == 0 {
+					t.Errorf("%v with weights %v: site %d has no cell", weighting, weights, i)
+				}
+			}
+		}
+	}
+}
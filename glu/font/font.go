@@ -16,19 +16,17 @@ var fontFragmentShaderSource string
 //go:embed font.vert
 var fontVertexShaderSource string
 
-func newFontProgram() glu.ShaderProgram {
-	shaders := []glu.Shader{
-		glu.CompileShader(fontVertexShaderSource, glu.VERTEX_SHADER),
-		glu.CompileShader(fontFragmentShaderSource, glu.FRAGMENT_SHADER),
-	}
-
-	return glu.LinkShaders(shaders)
+func newFontProgram() (glu.ShaderProgram, error) {
+	return glu.NewShaderProgram(glu.WithGlobals(fontVertexShaderSource), fontFragmentShaderSource)
 }
 
 // Loads the specified font bytes at the given scale. We need the monitor scale to adjust the font size.
 func NewFont(buf []byte, scale int32, scaleX float32, scaleY float32) (*Font, error) {
 
-	program := newFontProgram()
+	program, err := newFontProgram()
+	if err != nil {
+		return nil, err
+	}
 
 	upscale := max(scaleX, scaleY)
 	if upscale == 0 {
@@ -38,10 +36,14 @@ func NewFont(buf []byte, scale int32, scaleX float32, scaleY float32) (*Font, er
 	fd := bytes.NewReader(buf)
 	f, err := LoadTrueTypeFont(program, fd, scale, upscale, 32, 127)
 	if err != nil {
+		program.Delete()
 		return nil, err
 	}
 
-	f.SetColor(1.0, 1.0, 1.0, 1.0) // Set the default color to white
+	// Set the default color to white
+	if err := f.SetColor(1.0, 1.0, 1.0, 1.0); err != nil {
+		return nil, err
+	}
 
 	return f, nil
 }
@@ -68,19 +70,17 @@ func NewFont(buf []byte, scale int32, scaleX float32, scaleY float32) (*Font, er
 // }
 
 // SetColor allows you to set the text color to be used when you draw the text
-func (f *Font) SetColor(red float32, green float32, blue float32, alpha float32) {
+func (f *Font) SetColor(red float32, green float32, blue float32, alpha float32) error {
 	f.program.Use()
 	defer f.program.Unuse()
 	color := [4]float32{red, green, blue, alpha}
-	f.program.SetUniform4f("textColor", color)
 	f.color = color
+	return f.program.SetUniform4f("textColor", color)
 }
 
-// UpdateResolution used to recalibrate fonts for new window size
+// UpdateResolution used to recalibrate fonts for new window size. The shader gets the size from
+// glu.Globals.WindowSize, which must be the same.
 func (f *Font) UpdateResolution(windowWidth int, windowHeight int) {
-	f.program.Use()
-	defer f.program.Unuse()
-	f.program.SetUniform2f("u_resolution", [2]float32{float32(windowWidth), float32(windowHeight)})
 	f.windowWidth = windowWidth
 	f.windowHeight = windowHeight
 }
@@ -106,7 +106,6 @@ func (f *Font) Printf(x_norm, y_norm float32, scale float32, fs string, argv ...
 	f.program.Use()
 	defer f.program.Unuse()
 
-	gl.ActiveTexture(gl.TEXTURE0)
 	f.vertex_array.Bind()
 	defer f.vertex_array.Unbind()
 	defer gl.BindTexture(gl.TEXTURE_2D, 0)
@@ -152,7 +151,7 @@ func (f *Font) Printf(x_norm, y_norm float32, scale float32, fs string, argv ...
 			xpos + w, ypos + h, 1.0, 1.0,
 		}
 
-		gl.BindTexture(gl.TEXTURE_2D, ch.textureID)
+		ch.texture.Bind(0)
 		f.vertex_array.BufferData(vertices)
 		gl.DrawArrays(gl.TRIANGLE_STRIP, 0, 4)
 		x += float32((ch.advance >> 6)) * scale * _MAGIC
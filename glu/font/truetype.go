@@ -36,12 +36,12 @@ type Font struct {
 }
 
 type character struct {
-	textureID uint32 // ID handle of the glyph texture
-	width     int    //glyph width
-	height    int    //glyph height
-	advance   int    //glyph advance
-	bearingH  int    //glyph bearing horizontal
-	bearingV  int    //glyph bearing vertical
+	texture  *glu.Texture //glyph texture
+	width    int          //glyph width
+	height   int          //glyph height
+	advance  int          //glyph advance
+	bearingH int          //glyph bearing horizontal
+	bearingV int          //glyph bearing vertical
 }
 
 // GenerateGlyphs builds a set of textures based on a ttf files gylphs
@@ -117,23 +117,13 @@ func (f *Font) GenerateGlyphs(low, high rune) error {
 		}
 
 		// Generate texture
-		var texture uint32
-		gl.GenTextures(1, &texture)
-		gl.BindTexture(gl.TEXTURE_2D, texture)
-		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
-		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
-		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
-		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
-		gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA, int32(rgba.Rect.Dx()), int32(rgba.Rect.Dy()), 0,
-			gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(rgba.Pix))
-
-		char.textureID = texture
+		char.texture = glu.NewTextureFromImage(rgba)
+		char.texture.SetFilter(gl.LINEAR, gl.LINEAR)
 
 		//add char to fontChar list
 		f.fontChar[ch] = char
 	}
 
-	gl.BindTexture(gl.TEXTURE_2D, 0)
 	return nil
 }
 
@@ -170,26 +160,18 @@ func LoadTrueTypeFont(
 		return nil, err
 	}
 
-	// Configure VAO/VBO for texture quads
-	gl.GenVertexArrays(1, &f.vertex_array.Vao)
-	gl.GenBuffers(1, &f.vertex_array.Vbo)
-	gl.BindVertexArray(f.vertex_array.Vao)
-	gl.BindBuffer(gl.ARRAY_BUFFER, f.vertex_array.Vbo)
-
-	// gl.BufferData(gl.ARRAY_BUFFER, 0*4*4, nil, gl.STATIC_DRAW)
-
-	vertAttrib := program.GetAttribLocation("vert")
-	gl.EnableVertexAttribArray(vertAttrib)
-	gl.VertexAttribPointerWithOffset(vertAttrib, 2, gl.FLOAT, false, 4*4, 0)
-	defer gl.DisableVertexAttribArray(vertAttrib)
-
-	texCoordAttrib := program.GetAttribLocation("vertTexCoord")
-	gl.EnableVertexAttribArray(texCoordAttrib)
-	gl.VertexAttribPointerWithOffset(texCoordAttrib, 2, gl.FLOAT, false, 4*4, 2*4)
-	defer gl.DisableVertexAttribArray(texCoordAttrib)
-
-	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
-	gl.BindVertexArray(0)
+	// Configure VAO/VBO for texture quads: position and texture coordinates, interleaved. Every
+	// glyph overwrites the quad.
+	f.vertex_array, err = glu.NewVertexArrayWithLayout(program, glu.VertexBufferLayout{
+		Attributes: []glu.VertexAttribute{
+			{Name: "vert", Size: 2, Type: gl.FLOAT, Offset: 0},
+			{Name: "vertTexCoord", Size: 2, Type: gl.FLOAT, Offset: 2 * 4},
+		},
+		Usage: glu.StreamDraw,
+	})
+	if err != nil {
+		return nil, err
+	}
 
 	return f, nil
 }
@@ -0,0 +1,198 @@
+package glu
+
+import (
+	"errors"
+	"fmt"
+	"image"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// Returned when a framebuffer cannot be rendered into, wrapped with the reason.
+var ErrFramebufferIncomplete = errors.New("framebuffer is not complete")
+
+// A Framebuffer renders into textures instead of the window.
+type Framebuffer struct {
+	Fbo uint32
+	// The color textures, in the order of the layout. Fragment shader output n, i.e. the one
+	// declared with layout(location = n), goes to Colors[n].
+	Colors []*Texture
+	// The first color texture, nil if there are none
+	Texture *Texture
+	// The depth texture, nil without a depth buffer
+	Depth  *Texture
+	Width  int32
+	Height int32
+
+	// Framebuffer, viewport and scissor box to restore on Unbind
+	prevFbo      int32
+	prevViewport [4]int32
+	prevScissor  [4]int32
+}
+
+// The attachments of a framebuffer.
+type FramebufferLayout struct {
+	// Formats of the color textures, one for every output of the fragment shader
+	Colors []TextureFormat
+	// Format of the depth texture, e.g. Depth24. The zero value for no depth buffer.
+	Depth TextureFormat
+}
+
+// Create a framebuffer with a single color texture of the given format.
+func NewFramebuffer(width, height int32, format TextureFormat) (*Framebuffer, error) {
+	return NewFramebufferWithLayout(width, height, FramebufferLayout{Colors: []TextureFormat{format}})
+}
+
+// Create a framebuffer with a texture for every attachment of the layout.
+func NewFramebufferWithLayout(width, height int32, layout FramebufferLayout) (*Framebuffer, error) {
+	var maxDrawBuffers int32
+	gl.GetIntegerv(gl.MAX_DRAW_BUFFERS, &maxDrawBuffers)
+	if len(layout.Colors) > int(maxDrawBuffers) {
+		return nil, fmt.Errorf("%w: %d color attachments, the driver supports %d", ErrFramebufferIncomplete, len(layout.Colors), maxDrawBuffers)
+	}
+
+	fb := &Framebuffer{}
+	gl.GenFramebuffers(1, &fb.Fbo)
+	previous := fb.bindDraw()
+	defer gl.BindFramebuffer(gl.DRAW_FRAMEBUFFER, previous)
+
+	drawBuffers := make([]uint32, len(layout.Colors))
+	for i, format := range layout.Colors {
+		texture := NewTexture(0, 0, format)
+		fb.Colors = append(fb.Colors, texture)
+		drawBuffers[i] = gl.COLOR_ATTACHMENT0 + uint32(i)
+		gl.FramebufferTexture2D(gl.DRAW_FRAMEBUFFER, drawBuffers[i], gl.TEXTURE_2D, texture.ID, 0)
+	}
+	if len(drawBuffers) > 0 {
+		fb.Texture = fb.Colors[0]
+		gl.DrawBuffers(int32(len(drawBuffers)), &drawBuffers[0])
+	} else {
+		gl.DrawBuffer(gl.NONE)
+		gl.ReadBuffer(gl.NONE)
+	}
+	if layout.Depth != (TextureFormat{}) {
+		fb.Depth = NewTexture(0, 0, layout.Depth)
+		gl.FramebufferTexture2D(gl.DRAW_FRAMEBUFFER, gl.DEPTH_ATTACHMENT, gl.TEXTURE_2D, fb.Depth.ID, 0)
+	}
+
+	if err := fb.Resize(width, height); err != nil {
+		fb.Delete()
+		return nil, err
+	}
+	return fb, nil
+}
+
+// Bind the framebuffer for drawing, and return the one bound before.
+func (fb *Framebuffer) bindDraw() uint32 {
+	var previous int32
+	gl.GetIntegerv(gl.DRAW_FRAMEBUFFER_BINDING, &previous)
+	gl.BindFramebuffer(gl.DRAW_FRAMEBUFFER, fb.Fbo)
+	return uint32(previous)
+}
+
+// Reallocate the textures at a new size. The contents are lost.
+func (fb *Framebuffer) Resize(width, height int32) error {
+	if width <= 0 || height <= 0 {
+		return fmt.Errorf("%w: %dx%d is empty", ErrFramebufferIncomplete, width, height)
+	}
+	var maxSize int32
+	gl.GetIntegerv(gl.MAX_TEXTURE_SIZE, &maxSize)
+	if width > maxSize || height > maxSize {
+		return fmt.Errorf("%w: %dx%d is larger than the maximum texture size of %d", ErrFramebufferIncomplete, width, height, maxSize)
+	}
+
+	fb.Width = width
+	fb.Height = height
+	for _, texture := range fb.Colors {
+		texture.Resize(width, height)
+	}
+	if fb.Depth != nil {
+		fb.Depth.Resize(width, height)
+	}
+	return fb.check()
+}
+
+// Why a framebuffer is incomplete, by the status from glCheckFramebufferStatus
+var framebufferStatusReasons = map[uint32]string{
+	gl.FRAMEBUFFER_UNDEFINED:                     "there is no default framebuffer",
+	gl.FRAMEBUFFER_INCOMPLETE_ATTACHMENT:         "an attachment has no size or a format which cannot be rendered into",
+	gl.FRAMEBUFFER_INCOMPLETE_MISSING_ATTACHMENT: "it has no attachments",
+	gl.FRAMEBUFFER_INCOMPLETE_DRAW_BUFFER:        "a draw buffer has no attachment",
+	gl.FRAMEBUFFER_INCOMPLETE_READ_BUFFER:        "the read buffer has no attachment",
+	gl.FRAMEBUFFER_UNSUPPORTED:                   "the driver does not support this combination of formats",
+	gl.FRAMEBUFFER_INCOMPLETE_MULTISAMPLE:        "the attachments have different numbers of samples",
+	gl.FRAMEBUFFER_INCOMPLETE_LAYER_TARGETS:      "the attachments have different numbers of layers",
+}
+
+// Check that the framebuffer can be rendered into.
+func (fb *Framebuffer) check() error {
+	previous := fb.bindDraw()
+	defer gl.BindFramebuffer(gl.DRAW_FRAMEBUFFER, previous)
+
+	status := gl.CheckFramebufferStatus(gl.DRAW_FRAMEBUFFER)
+	if status == gl.FRAMEBUFFER_COMPLETE {
+		return nil
+	}
+	reason, ok := framebufferStatusReasons[status]
+	if !ok {
+		reason = fmt.Sprintf("status 0x%x", status)
+	}
+	return fmt.Errorf("%w: %dx%d %s: %s", ErrFramebufferIncomplete, fb.Width, fb.Height, fb.describe(), reason)
+}
+
+// The formats of the attachments, e.g. "RGBA8 + DEPTH24"
+func (fb *Framebuffer) describe() string {
+	description := ""
+	for i, texture := range fb.Colors {
+		if i > 0 {
+			description += ", "
+		}
+		description += texture.Format.String()
+	}
+	if fb.Depth != nil {
+		description += " + " + fb.Depth.Format.String()
+	}
+	return description
+}
+
+// Render into the framebuffer. The viewport and scissor box are set to cover all of it.
+func (fb *Framebuffer) Bind() {
+	gl.GetIntegerv(gl.FRAMEBUFFER_BINDING, &fb.prevFbo)
+	gl.GetIntegerv(gl.VIEWPORT, &fb.prevViewport[0])
+	gl.GetIntegerv(gl.SCISSOR_BOX, &fb.prevScissor[0])
+	gl.BindFramebuffer(gl.FRAMEBUFFER, fb.Fbo)
+	gl.Viewport(0, 0, fb.Width, fb.Height)
+	gl.Scissor(0, 0, fb.Width, fb.Height)
+}
+
+// Go back to rendering into whatever was bound before Bind, usually the window, and restore the
+// viewport and scissor box.
+func (fb *Framebuffer) Unbind() {
+	gl.BindFramebuffer(gl.FRAMEBUFFER, uint32(fb.prevFbo))
+	gl.Viewport(fb.prevViewport[0], fb.prevViewport[1], fb.prevViewport[2], fb.prevViewport[3])
+	gl.Scissor(fb.prevScissor[0], fb.prevScissor[1], fb.prevScissor[2], fb.prevScissor[3])
+}
+
+// Read one of the color textures back into an image, with the top row first like the window
+// shows it. Only for R8 and RGBA8 textures.
+func (fb *Framebuffer) ReadImage(attachment int) (*image.RGBA, error) {
+	if attachment < 0 || attachment >= len(fb.Colors) {
+		return nil, fmt.Errorf("framebuffer has no color attachment %d", attachment)
+	}
+	img, err := fb.Colors[attachment].ReadImage()
+	if err != nil {
+		return nil, err
+	}
+	flipRows(img)
+	return img, nil
+}
+
+func (fb *Framebuffer) Delete() {
+	gl.DeleteFramebuffers(1, &fb.Fbo)
+	for _, texture := range fb.Colors {
+		texture.Delete()
+	}
+	if fb.Depth != nil {
+		fb.Depth.Delete()
+	}
+}
@@ -0,0 +1,42 @@
+package glu
+
+import "strings"
+
+// The uniform buffer binding point of the Globals block. Programs which declare the block are
+// bound to it when they are linked.
+const GlobalsBinding = 0
+
+// The values of the Globals uniform block, the state which all the programs share. Use it with
+// a UniformBuffer on GlobalsBinding, and WithGlobals to declare the block in the shaders.
+type Globals struct {
+	// Size of the drawing area in pixels
+	Resolution [2]float32
+	// Size of the window in screen coordinates. Differs from Resolution on high DPI displays.
+	WindowSize [2]float32
+	// Mouse position in pixels, from the top left corner
+	Mouse [2]float32
+	// Seconds since the start, and the number of the frame
+	Time  float32
+	Frame int32
+	// The view transform. Maps the drawing area, from (0,0) at the bottom left to (1,1) at the
+	// top right, to the coordinates of whatever is drawn.
+	View Mat3
+}
+
+// The GLSL declaration of the Globals block. Must match Globals.
+const globalsSource = `layout(std140) uniform Globals {
+    vec2 resolution;
+    vec2 window_size;
+    vec2 mouse;
+    float time;
+    int frame;
+    mat3 view;
+} globals;`
+
+// Declare the Globals block in a shader, right after its #version line. The members are then
+// available as globals.resolution and so on. In compiler errors the declaration is source
+// string 2, the shader itself keeps its line numbers.
+func WithGlobals(source string) string {
+	version, rest, _ := strings.Cut(source, "\n")
+	return version + "\n#line 1 2\n" + globalsSource + "\n#line 2 0\n" + rest
+}
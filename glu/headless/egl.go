@@ -0,0 +1,104 @@
+//go:build egl
+
+package headless
+
+/*
+#cgo pkg-config: egl
+#include <stdlib.h>
+#include <EGL/egl.h>
+#include <EGL/eglext.h>
+
+#ifndef EGL_PLATFORM_SURFACELESS_MESA
+#define EGL_PLATFORM_SURFACELESS_MESA 0x31DD
+#endif
+
+// Prefer the surfaceless platform, which needs no display server at all
+static EGLDisplay headlessDisplay() {
+	PFNEGLGETPLATFORMDISPLAYEXTPROC getPlatformDisplay =
+		(PFNEGLGETPLATFORMDISPLAYEXTPROC)eglGetProcAddress("eglGetPlatformDisplayEXT");
+	if (getPlatformDisplay != NULL) {
+		EGLDisplay display = getPlatformDisplay(EGL_PLATFORM_SURFACELESS_MESA, EGL_DEFAULT_DISPLAY, NULL);
+		if (display != EGL_NO_DISPLAY) {
+			return display;
+		}
+	}
+	return eglGetDisplay(EGL_DEFAULT_DISPLAY);
+}
+
+static EGLContext headlessContext(EGLDisplay display) {
+	// The default surface type is window, which there are none of without a display server
+	EGLint config_attributes[] = {
+		EGL_SURFACE_TYPE, EGL_PBUFFER_BIT,
+		EGL_RENDERABLE_TYPE, EGL_OPENGL_BIT,
+		EGL_NONE,
+	};
+	EGLConfig config;
+	EGLint num_configs;
+	if (!eglChooseConfig(display, config_attributes, &config, 1, &num_configs) || num_configs < 1) {
+		return EGL_NO_CONTEXT;
+	}
+	EGLint context_attributes[] = {
+		EGL_CONTEXT_MAJOR_VERSION, 3,
+		EGL_CONTEXT_MINOR_VERSION, 3,
+		EGL_CONTEXT_OPENGL_PROFILE_MASK, EGL_CONTEXT_OPENGL_CORE_PROFILE_BIT,
+		EGL_CONTEXT_OPENGL_FORWARD_COMPATIBLE, EGL_TRUE,
+		EGL_NONE,
+	};
+	return eglCreateContext(display, config, EGL_NO_CONTEXT, context_attributes);
+}
+*/
+import "C"
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+// Backend is the name of the backend selected by the build tags.
+const Backend = "egl"
+
+type Context struct {
+	// cgo turns EGLDisplay into a uintptr, so no display is 0 rather than nil
+	display C.EGLDisplay
+	context C.EGLContext
+}
+
+// Create a context and make it current.
+func NewContext() (*Context, error) {
+	c := &Context{display: C.headlessDisplay()}
+	if c.display == 0 {
+		return nil, fmt.Errorf("egl: no display")
+	}
+	var major, minor C.EGLint
+	if C.eglInitialize(c.display, &major, &minor) == C.EGL_FALSE {
+		return nil, fmt.Errorf("egl: eglInitialize failed: 0x%x", C.eglGetError())
+	}
+	if C.eglBindAPI(C.EGL_OPENGL_API) == C.EGL_FALSE {
+		C.eglTerminate(c.display)
+		return nil, fmt.Errorf("egl: no desktop OpenGL: 0x%x", C.eglGetError())
+	}
+	c.context = C.headlessContext(c.display)
+	if c.context == nil {
+		C.eglTerminate(c.display)
+		return nil, fmt.Errorf("egl: failed to create an OpenGL 3.3 core context: 0x%x", C.eglGetError())
+	}
+	// Without a surface there is no default framebuffer, everything goes into framebuffer objects
+	if C.eglMakeCurrent(c.display, nil, nil, c.context) == C.EGL_FALSE {
+		c.Destroy()
+		return nil, fmt.Errorf("egl: eglMakeCurrent failed (no surfaceless context support?): 0x%x", C.eglGetError())
+	}
+	return c, nil
+}
+
+// Look up an OpenGL function. For glu.InitWithProcAddrFunc.
+func (c *Context) GetProcAddr(name string) unsafe.Pointer {
+	cname := C.CString(name)
+	defer C.free(unsafe.Pointer(cname))
+	return unsafe.Pointer(C.eglGetProcAddress(cname))
+}
+
+func (c *Context) Destroy() {
+	C.eglMakeCurrent(c.display, nil, nil, nil)
+	C.eglDestroyContext(c.display, c.context)
+	C.eglTerminate(c.display)
+}
@@ -0,0 +1,46 @@
+//go:build !egl && !osmesa
+
+package headless
+
+import (
+	"fmt"
+	"unsafe"
+
+	"github.com/go-gl/glfw/v3.3/glfw"
+)
+
+// Backend is the name of the backend selected by the build tags.
+const Backend = "glfw"
+
+type Context struct {
+	window *glfw.Window
+}
+
+// Create a context and make it current. The context belongs to a window which is never shown.
+func NewContext() (*Context, error) {
+	if err := glfw.Init(); err != nil {
+		return nil, fmt.Errorf("glfw: %w (a display is needed, build with -tags egl or -tags osmesa to do without)", err)
+	}
+	glfw.WindowHint(glfw.Visible, glfw.False)
+	glfw.WindowHint(glfw.ContextVersionMajor, 3)
+	glfw.WindowHint(glfw.ContextVersionMinor, 3)
+	glfw.WindowHint(glfw.OpenGLProfile, glfw.OpenGLCoreProfile)
+	glfw.WindowHint(glfw.OpenGLForwardCompatible, glfw.True)
+	window, err := glfw.CreateWindow(1, 1, "headless", nil, nil)
+	if err != nil {
+		glfw.Terminate()
+		return nil, fmt.Errorf("glfw: %w", err)
+	}
+	window.MakeContextCurrent()
+	return &Context{window}, nil
+}
+
+// Look up an OpenGL function. For glu.InitWithProcAddrFunc.
+func (c *Context) GetProcAddr(name string) unsafe.Pointer {
+	return glfw.GetProcAddress(name)
+}
+
+func (c *Context) Destroy() {
+	c.window.Destroy()
+	glfw.Terminate()
+}
@@ -0,0 +1,12 @@
+// Package headless creates an OpenGL 3.3 core context without a window, for rendering into a
+// glu.Framebuffer on machines with no display. The backend is selected with build tags:
+//
+//	-tags egl     EGL with a surfaceless display. Needs no display server, and runs on the
+//	              GPU if there is one, or on Mesa's software rasterizer if not.
+//	-tags osmesa  OSMesa software rendering. Needs neither a display nor a GPU.
+//	(no tag)      A hidden GLFW window. Needs a display server, e.g. Xvfb.
+//
+// The context is current on the thread which created it, so lock the thread with
+// runtime.LockOSThread first, just like with GLFW. Then initialize the gl bindings with
+// glu.InitWithProcAddrFunc(context.GetProcAddr).
+package headless
@@ -0,0 +1,63 @@
+//go:build osmesa && !egl
+
+package headless
+
+/*
+#cgo pkg-config: osmesa
+#include <stdlib.h>
+#include <GL/osmesa.h>
+
+static OSMesaContext headlessContext() {
+	int attributes[] = {
+		OSMESA_FORMAT, OSMESA_RGBA,
+		OSMESA_PROFILE, OSMESA_CORE_PROFILE,
+		OSMESA_CONTEXT_MAJOR_VERSION, 3,
+		OSMESA_CONTEXT_MINOR_VERSION, 3,
+		0,
+	};
+	return OSMesaCreateContextAttribs(attributes, NULL);
+}
+*/
+import "C"
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+// Backend is the name of the backend selected by the build tags.
+const Backend = "osmesa"
+
+type Context struct {
+	context C.OSMesaContext
+
+	// OSMesa needs a color buffer to make the context current. Nothing is drawn into it, all
+	// rendering goes into framebuffer objects.
+	buffer unsafe.Pointer
+}
+
+// Create a context and make it current.
+func NewContext() (*Context, error) {
+	c := &Context{context: C.headlessContext()}
+	if c.context == nil {
+		return nil, fmt.Errorf("osmesa: failed to create an OpenGL 3.3 core context")
+	}
+	c.buffer = C.malloc(4)
+	if C.OSMesaMakeCurrent(c.context, c.buffer, C.GL_UNSIGNED_BYTE, 1, 1) == C.GL_FALSE {
+		c.Destroy()
+		return nil, fmt.Errorf("osmesa: OSMesaMakeCurrent failed")
+	}
+	return c, nil
+}
+
+// Look up an OpenGL function. For glu.InitWithProcAddrFunc.
+func (c *Context) GetProcAddr(name string) unsafe.Pointer {
+	cname := C.CString(name)
+	defer C.free(unsafe.Pointer(cname))
+	return unsafe.Pointer(C.OSMesaGetProcAddress(cname))
+}
+
+func (c *Context) Destroy() {
+	C.OSMesaDestroyContext(c.context)
+	C.free(c.buffer)
+}
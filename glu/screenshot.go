@@ -0,0 +1,42 @@
+package glu
+
+import (
+	"image"
+	"image/png"
+	"os"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// Read a rectangle of the framebuffer bound for reading, e.g. the back buffer of the window,
+// into an image. The rows are flipped so that the top row comes first, like on the screen.
+func ReadPixels(x, y, width, height int32) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, int(width), int(height)))
+	gl.PixelStorei(gl.PACK_ALIGNMENT, 1)
+	gl.ReadPixels(x, y, width, height, gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(img.Pix))
+	flipRows(img)
+	return img
+}
+
+// OpenGL rows go from the bottom up, image rows from the top down
+func flipRows(img *image.RGBA) {
+	row := make([]byte, img.Stride)
+	for top, bottom := 0, len(img.Pix)-img.Stride; top < bottom; top, bottom = top+img.Stride, bottom-img.Stride {
+		copy(row, img.Pix[top:top+img.Stride])
+		copy(img.Pix[top:top+img.Stride], img.Pix[bottom:bottom+img.Stride])
+		copy(img.Pix[bottom:bottom+img.Stride], row)
+	}
+}
+
+// Write an image into a PNG file.
+func SavePNG(path string, img image.Image) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(file, img); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
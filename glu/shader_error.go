@@ -0,0 +1,97 @@
+package glu
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ShaderError is returned when a shader fails to compile or a program fails to link.
+type ShaderError struct {
+	// The stage which failed to compile. Zero for link errors.
+	Stage ShaderType
+	// The source of the shader. Empty for link errors.
+	Source string
+	// The info log, exactly as the driver wrote it
+	Log string
+	// The info log split into messages
+	Diagnostics []Diagnostic
+}
+
+// A single message from a shader info log.
+type Diagnostic struct {
+	// The source string number. Always 0, unless the source changes it with a #line directive.
+	Source int
+	// Position in the source, starting at 1. Zero when the driver did not say.
+	Line   int
+	Column int
+	// "error" or "warning"
+	Severity string
+	Message  string
+}
+
+func (d Diagnostic) String() string {
+	source := ""
+	if d.Source != 0 {
+		source = fmt.Sprintf("(source %d) ", d.Source)
+	}
+	switch {
+	case d.Line > 0 && d.Column > 0:
+		return fmt.Sprintf("%s%d:%d: %s: %s", source, d.Line, d.Column, d.Severity, d.Message)
+	case d.Line > 0:
+		return fmt.Sprintf("%s%d: %s: %s", source, d.Line, d.Severity, d.Message)
+	}
+	return fmt.Sprintf("%s: %s", d.Severity, d.Message)
+}
+
+func (e *ShaderError) Error() string {
+	var b strings.Builder
+	if e.Stage == 0 {
+		b.WriteString("failed to link shader program")
+	} else {
+		fmt.Fprintf(&b, "failed to compile %v shader", e.Stage)
+	}
+	for _, d := range e.Diagnostics {
+		b.WriteString("\n  ")
+		b.WriteString(d.String())
+	}
+	return b.String()
+}
+
+// The info log formats of the common drivers. The leading number is the source string number.
+var (
+	// Mesa: 0:12(5): error: syntax error, unexpected ...
+	mesaDiagnostic = regexp.MustCompile(`^(\d+):(\d+)\((\d+)\): (error|warning): (.*)$`)
+	// NVIDIA: 0(12) : error C1008: undefined variable "foo"
+	nvidiaDiagnostic = regexp.MustCompile(`^(\d+)\((\d+)\) ?: (error|warning)(?: \w+)?: (.*)$`)
+	// AMD, Intel on Windows and Apple: ERROR: 0:12: 'foo' : undeclared identifier
+	glslangDiagnostic = regexp.MustCompile(`^(ERROR|WARNING): (\d+):(\d+): (.*)$`)
+)
+
+// Split an info log into diagnostics. Lines in an unknown format become diagnostics without a
+// position.
+func parseInfoLog(log string) []Diagnostic {
+	var diagnostics []Diagnostic
+	for _, line := range strings.Split(log, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if m := mesaDiagnostic.FindStringSubmatch(line); m != nil {
+			diagnostics = append(diagnostics, Diagnostic{atoi(m[1]), atoi(m[2]), atoi(m[3]), m[4], m[5]})
+		} else if m := nvidiaDiagnostic.FindStringSubmatch(line); m != nil {
+			diagnostics = append(diagnostics, Diagnostic{atoi(m[1]), atoi(m[2]), 0, m[3], m[4]})
+		} else if m := glslangDiagnostic.FindStringSubmatch(line); m != nil {
+			diagnostics = append(diagnostics, Diagnostic{atoi(m[2]), atoi(m[3]), 0, strings.ToLower(m[1]), m[4]})
+		} else {
+			diagnostics = append(diagnostics, Diagnostic{Severity: "error", Message: line})
+		}
+	}
+	return diagnostics
+}
+
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
@@ -0,0 +1,82 @@
+package glu
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseInfoLog(t *testing.T) {
+	tests := []struct {
+		name string
+		log  string
+		want []Diagnostic
+	}{
+		{
+			name: "mesa",
+			log:  "0:12(5): error: `foo' undeclared\n0:3(1): warning: extension `GL_ARB_foo' unsupported\n",
+			want: []Diagnostic{
+				{Source: 0, Line: 12, Column: 5, Severity: "error", Message: "`foo' undeclared"},
+				{Source: 0, Line: 3, Column: 1, Severity: "warning", Message: "extension `GL_ARB_foo' unsupported"},
+			},
+		},
+		{
+			name: "mesa source string",
+			log:  "2:1(14): error: syntax error, unexpected IDENTIFIER",
+			want: []Diagnostic{{Source: 2, Line: 1, Column: 14, Severity: "error", Message: "syntax error, unexpected IDENTIFIER"}},
+		},
+		{
+			name: "nvidia",
+			log:  "0(12) : error C1008: undefined variable \"foo\"\r\n0(20) : warning C7050: \"bar\" might be used before being initialized\r\n",
+			want: []Diagnostic{
+				{Source: 0, Line: 12, Severity: "error", Message: `undefined variable "foo"`},
+				{Source: 0, Line: 20, Severity: "warning", Message: `"bar" might be used before being initialized`},
+			},
+		},
+		{
+			name: "nvidia without space or code",
+			log:  "1(4): error: unexpected token",
+			want: []Diagnostic{{Source: 1, Line: 4, Severity: "error", Message: "unexpected token"}},
+		},
+		{
+			name: "amd and intel",
+			log:  "ERROR: 0:12: 'foo' : undeclared identifier\nWARNING: 0:7: 'bar' : unused variable\nERROR: 1 compilation errors.  No code generated.\n",
+			want: []Diagnostic{
+				{Source: 0, Line: 12, Severity: "error", Message: "'foo' : undeclared identifier"},
+				{Source: 0, Line: 7, Severity: "warning", Message: "'bar' : unused variable"},
+				{Severity: "error", Message: "ERROR: 1 compilation errors.  No code generated."},
+			},
+		},
+		{
+			name: "unparseable",
+			log:  "Fragment shader failed to compile with the following errors:\n\n  error: unresolved reference to function foo  \n",
+			want: []Diagnostic{
+				{Severity: "error", Message: "Fragment shader failed to compile with the following errors:"},
+				{Severity: "error", Message: "error: unresolved reference to function foo"},
+			},
+		},
+		{
+			name: "empty",
+			log:  "\n  \n",
+		},
+	}
+	for _, test := range tests {
+		if got := parseInfoLog(test.log); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %+v, want %+v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestShaderErrorString(t *testing.T) {
+	err := &ShaderError{Diagnostics: []Diagnostic{
+		{Source: 0, Line: 12, Column: 5, Severity: "error", Message: "`foo' undeclared"},
+		{Source: 2, Line: 3, Severity: "warning", Message: "unused"},
+		{Severity: "error", Message: "no position"},
+	}}
+	want := "failed to link shader program\n" +
+		"  12:5: error: `foo' undeclared\n" +
+		"  (source 2) 3: warning: unused\n" +
+		"  error: no position"
+	if err.Error() != want {
+		t.Errorf("got\n%s\nwant\n%s", err.Error(), want)
+	}
+}
@@ -1,7 +1,10 @@
 package glu
 
 import (
-	"log"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
 	"unsafe"
 
 	"github.com/go-gl/gl/v3.3-core/gl"
@@ -10,21 +13,26 @@ import (
 type getGlParam func(uint32, uint32, *int32)
 type getInfoLog func(uint32, int32, *int32, *uint8)
 
+// Check the status of a shader or program object. Returns the info log if it failed.
 func checkGlError(
 	glObject uint32,
 	errorParam uint32,
 	getParamFn getGlParam,
-	getInfoLogFn getInfoLog) string {
+	getInfoLogFn getInfoLog) (string, bool) {
 
 	var success int32
 	getParamFn(glObject, errorParam, &success)
-	if success != 1 {
-		var infoLog [512]byte
-		getInfoLogFn(glObject, 512, nil, (*uint8)(unsafe.Pointer(&infoLog)))
-		message := string(infoLog[:512])
-		return message
+	if success == gl.TRUE {
+		return "", true
 	}
-	return ""
+	var length int32
+	getParamFn(glObject, gl.INFO_LOG_LENGTH, &length)
+	if length == 0 {
+		return "", false
+	}
+	infoLog := make([]byte, length)
+	getInfoLogFn(glObject, length, nil, &infoLog[0])
+	return strings.TrimRight(string(infoLog), "\x00\n "), false
 }
 
 type Shader struct {
@@ -38,64 +46,132 @@ const (
 	FRAGMENT_SHADER ShaderType = gl.FRAGMENT_SHADER
 )
 
-// Compile the provided shader source and return the shader object.
-func CompileShader(source string, shader_type ShaderType) Shader {
+func (t ShaderType) String() string {
+	switch t {
+	case VERTEX_SHADER:
+		return "vertex"
+	case FRAGMENT_SHADER:
+		return "fragment"
+	}
+	return fmt.Sprintf("ShaderType(0x%x)", uint32(t))
+}
+
+// Compile the provided shader source and return the shader object. If it fails to compile the
+// error is a *ShaderError.
+func CompileShader(source string, shader_type ShaderType) (Shader, error) {
 	program := gl.CreateShader(uint32(shader_type))
 	source_chars, free_func := gl.Strs(source + "\x00")
 	defer free_func()
 	gl.ShaderSource(program, 1, source_chars, nil)
 	gl.CompileShader(program)
-	message := checkGlError(program, gl.COMPILE_STATUS, gl.GetShaderiv, gl.GetShaderInfoLog)
-
-	if message != "" {
-		log.Fatalln("ERROR::SHADER::COMPILATION_FAILED. Source:\n", source, "\n", message)
+	message, ok := checkGlError(program, gl.COMPILE_STATUS, gl.GetShaderiv, gl.GetShaderInfoLog)
+
+	if !ok {
+		gl.DeleteShader(program)
+		return Shader{}, &ShaderError{
+			Stage:       shader_type,
+			Source:      source,
+			Log:         message,
+			Diagnostics: parseInfoLog(message),
+		}
 	}
 
-	return Shader{program}
+	return Shader{program}, nil
 }
 
 type ShaderProgram struct {
 	program uint32
+	// The active uniforms by name, looked up once when the program is linked. Arrays are in it
+	// under every element name, "name[0]", "name[1]", ..., and under "name" for the first one.
+	uniforms map[string]Uniform
 }
 
 // Link the provided shaders in the order they were given and return the linked program.
-// The shader objects are not needed after they are linked into a program object, and they
-// should be deleted.
-func LinkShaders(shaders []Shader) ShaderProgram {
+// The shader objects are not needed after they are linked into a program object, so they are
+// deleted, also when linking fails. If it fails to link the error is a *ShaderError.
+func LinkShaders(shaders []Shader) (ShaderProgram, error) {
 	program := gl.CreateProgram()
 	for _, shader := range shaders {
 		gl.AttachShader(program, shader.program)
 	}
 	gl.LinkProgram(program)
-	message := checkGlError(program, gl.LINK_STATUS, gl.GetProgramiv, gl.GetProgramInfoLog)
-	if message != "" {
-		log.Fatalln("ERROR::PROGRAM::LINKING_FAILURE\n", message)
-	}
+	message, ok := checkGlError(program, gl.LINK_STATUS, gl.GetProgramiv, gl.GetProgramInfoLog)
 
 	// shader objects are not needed after they are linked into a program object
 	for _, shader := range shaders {
 		gl.DeleteShader(shader.program)
 	}
 
-	return ShaderProgram{program}
+	if !ok {
+		gl.DeleteProgram(program)
+		return ShaderProgram{}, &ShaderError{Log: message, Diagnostics: parseInfoLog(message)}
+	}
+
+	sp := ShaderProgram{program: program}
+	sp.uniforms = sp.uniformsByName()
+
+	// The Globals block is always on the same binding point, see WithGlobals
+	if index := gl.GetUniformBlockIndex(program, gl.Str("Globals\x00")); index != gl.INVALID_INDEX {
+		gl.UniformBlockBinding(program, index, GlobalsBinding)
+	}
+	return sp, nil
+}
+
+// Compile a vertex and a fragment shader and link them into a program. The shaders are deleted
+// whether it succeeds or not.
+func NewShaderProgram(vertexSource string, fragmentSource string) (ShaderProgram, error) {
+	vertexShader, err := CompileShader(vertexSource, VERTEX_SHADER)
+	if err != nil {
+		return ShaderProgram{}, err
+	}
+	fragmentShader, err := CompileShader(fragmentSource, FRAGMENT_SHADER)
+	if err != nil {
+		gl.DeleteShader(vertexShader.program)
+		return ShaderProgram{}, err
+	}
+	return LinkShaders([]Shader{vertexShader, fragmentShader})
+}
+
+// The program in use, so that Use can skip glUseProgram when it is already. 0 when unknown.
+// It assumes a single current context, and that programs are only bound through Use and
+// Unuse: Init and InitWithProcAddrFunc forget it for a new context, and anything calling
+// gl.UseProgram directly must call ForgetCurrentProgram.
+var currentProgram uint32
+
+// Forget which program is in use, after it was changed behind the back of Use, e.g. by a raw
+// gl.UseProgram or by making another context current. The next Use binds its program.
+func ForgetCurrentProgram() {
+	currentProgram = 0
 }
 
 func (sp ShaderProgram) Use() {
+	if currentProgram == sp.program {
+		return
+	}
 	gl.UseProgram(sp.program)
+	currentProgram = sp.program
 }
 
 func (sp ShaderProgram) Unuse() {
 	gl.UseProgram(0)
+	currentProgram = 0
 }
 
 func (sp ShaderProgram) Delete() {
+	// A new program may get the same name
+	if currentProgram == sp.program {
+		currentProgram = 0
+	}
 	gl.DeleteProgram(sp.program)
 }
 
 type Uniform struct {
 	name  string
 	utype uint32
-	size  int32
+	// Number of array elements, 1 for other uniforms. For an element of an array, the number of
+	// elements from this one to the end.
+	size     int32
+	location int32
 }
 
 func (sp ShaderProgram) GetActiveUniforms() []Uniform {
@@ -115,91 +191,417 @@ func (sp ShaderProgram) GetActiveUniforms() []Uniform {
 		name_null := make([]uint8, 256)
 		gl.GetActiveUniform(sp.program, uint32(i), 256, &name_len, &size, &gl_type, &name_null[0])
 		name := string(name_null[:name_len])
-		uniforms[i] = Uniform{name, gl_type, size}
+		location := gl.GetUniformLocation(sp.program, gl.Str(name+"\x00"))
+		uniforms[i] = Uniform{name, gl_type, size, location}
 	}
 
 	return uniforms
 }
 
-func (sp ShaderProgram) GetAttribLocation(name string) uint32 {
-	sp.Use()
+// Index the active uniforms by name, including every element of the arrays. Uniforms in
+// uniform blocks are left out, they are set through a UniformBuffer.
+func (sp ShaderProgram) uniformsByName() map[string]Uniform {
+	uniforms := map[string]Uniform{}
+	for _, uniform := range sp.GetActiveUniforms() {
+		if uniform.location == -1 {
+			continue
+		}
+		// Arrays are reported as "name[0]"
+		base, isArray := strings.CutSuffix(uniform.name, "[0]")
+		if !isArray {
+			uniforms[uniform.name] = uniform
+			continue
+		}
+		uniforms[base] = uniform
+		uniforms[uniform.name] = uniform
+		for i := int32(1); i < uniform.size; i++ {
+			name := fmt.Sprintf("%s[%d]", base, i)
+			location := gl.GetUniformLocation(sp.program, gl.Str(name+"\x00"))
+			uniforms[name] = Uniform{name, uniform.utype, uniform.size - i, location}
+		}
+	}
+	return uniforms
+}
+
+// Returned (wrapped) by the location getters and the uniform setters for names which are not
+// active in the program. Note that the GLSL compiler removes variables which do not affect the
+// output.
+var ErrUnknownAttribute = errors.New("unknown or inactive attribute")
+var ErrUnknownUniform = errors.New("unknown or inactive uniform")
+
+// Returned (wrapped) by the uniform setters when the GLSL type of the uniform does not match the
+// setter, e.g. SetUniform1f for a vec2.
+var ErrUniformType = errors.New("wrong uniform type")
+
+// When set, the uniform setters read every value back and check it, and setting a uniform which
+// is not active in the program is an error. Otherwise such uniforms are ignored, like OpenGL
+// does, since the GLSL compiler removes unused uniforms and which ones it removes differs from
+// driver to driver.
+var DebugUniforms = false
+
+func (sp ShaderProgram) GetAttribLocation(name string) (uint32, error) {
 	location := gl.GetAttribLocation(sp.program, gl.Str(name+"\x00"))
 	if location == -1 {
-		log.Fatalln("Invalid attribute name", name)
+		return 0, fmt.Errorf("%w: %s", ErrUnknownAttribute, name)
 	}
-	return uint32(location)
+	return uint32(location), nil
 }
 
-func (sp ShaderProgram) GetUniformLocation(name string) uint32 {
-	sp.Use()
-	location := gl.GetUniformLocation(sp.program, gl.Str(name+"\x00"))
-	if location == -1 {
-		log.Fatalln("Invalid uniform name", name)
+func (sp ShaderProgram) GetUniformLocation(name string) (uint32, error) {
+	uniform, ok := sp.uniforms[name]
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", ErrUnknownUniform, name)
 	}
-	return uint32(location)
+	return uint32(uniform.location), nil
 }
 
-func (sp ShaderProgram) SetUniform1f(name string, x float32) {
-	location := int32(sp.GetUniformLocation(name))
-	gl.Uniform1f(location, x)
+// Names of the GLSL types of the uniforms, for the error messages
+var glslTypeNames = map[uint32]string{
+	gl.FLOAT:             "float",
+	gl.FLOAT_VEC2:        "vec2",
+	gl.FLOAT_VEC3:        "vec3",
+	gl.FLOAT_VEC4:        "vec4",
+	gl.FLOAT_MAT2:        "mat2",
+	gl.FLOAT_MAT3:        "mat3",
+	gl.FLOAT_MAT4:        "mat4",
+	gl.INT:               "int",
+	gl.INT_VEC2:          "ivec2",
+	gl.INT_VEC3:          "ivec3",
+	gl.INT_VEC4:          "ivec4",
+	gl.UNSIGNED_INT:      "uint",
+	gl.UNSIGNED_INT_VEC2: "uvec2",
+	gl.UNSIGNED_INT_VEC3: "uvec3",
+	gl.UNSIGNED_INT_VEC4: "uvec4",
+	gl.BOOL:              "bool",
+	gl.BOOL_VEC2:         "bvec2",
+	gl.BOOL_VEC3:         "bvec3",
+	gl.BOOL_VEC4:         "bvec4",
+}
 
-	// read back the uniform value and check it
-	var value float32
-	gl.GetUniformfv(sp.program, location, &value)
+// The sampler types, which are set to a texture unit
+var samplerTypes = map[uint32]string{
+	gl.SAMPLER_1D:                    "sampler1D",
+	gl.SAMPLER_2D:                    "sampler2D",
+	gl.SAMPLER_3D:                    "sampler3D",
+	gl.SAMPLER_CUBE:                  "samplerCube",
+	gl.SAMPLER_2D_RECT:               "sampler2DRect",
+	gl.SAMPLER_2D_ARRAY:              "sampler2DArray",
+	gl.SAMPLER_2D_SHADOW:             "sampler2DShadow",
+	gl.SAMPLER_2D_MULTISAMPLE:        "sampler2DMS",
+	gl.SAMPLER_BUFFER:                "samplerBuffer",
+	gl.INT_SAMPLER_2D:                "isampler2D",
+	gl.INT_SAMPLER_2D_ARRAY:          "isampler2DArray",
+	gl.INT_SAMPLER_BUFFER:            "isamplerBuffer",
+	gl.UNSIGNED_INT_SAMPLER_2D:       "usampler2D",
+	gl.UNSIGNED_INT_SAMPLER_2D_ARRAY: "usampler2DArray",
+	gl.UNSIGNED_INT_SAMPLER_3D:       "usampler3D",
+}
 
-	if value != x {
-		log.Fatalln("Uniform value was not set correctly")
+func glslTypeName(utype uint32) string {
+	if name, ok := glslTypeNames[utype]; ok {
+		return name
+	}
+	if name, ok := samplerTypes[utype]; ok {
+		return name
 	}
+	return fmt.Sprintf("type 0x%x", utype)
 }
 
-func (sp ShaderProgram) SetUniform2f(name string, vec [2]float32) {
-	location := int32(sp.GetUniformLocation(name))
-	gl.Uniform2f(location, vec[0], vec[1])
+// Look up a uniform for a setter which sets count elements of one of the given types, and make
+// the program current so that it can be set. The location is -1, which OpenGL ignores, for
+// uniforms which are not active (unless DebugUniforms is set, then it is an error). Samplers
+// are accepted if the types include gl.INT, since they are set with glUniform1i.
+func (sp ShaderProgram) uniformLocation(name string, count int, types ...uint32) (int32, error) {
+	uniform, ok := sp.uniforms[name]
+	if !ok {
+		if DebugUniforms {
+			return -1, fmt.Errorf("%w: %s", ErrUnknownUniform, name)
+		}
+		return -1, nil
+	}
+	_, isSampler := samplerTypes[uniform.utype]
+	if !slices.Contains(types, uniform.utype) && !(isSampler && slices.Contains(types, gl.INT)) {
+		return -1, fmt.Errorf("%w: %s is %s, not %s", ErrUniformType, name, glslTypeName(uniform.utype), glslTypeName(types[0]))
+	}
+	if int32(count) > uniform.size {
+		return -1, fmt.Errorf("uniform %s has %d elements, not %d", name, uniform.size, count)
+	}
+	sp.Use()
+	return uniform.location, nil
+}
 
-	// read back the uniform value and check it
-	var value [2]float32
-	gl.GetUniformfv(sp.program, location, &value[0])
+// Returned when the value read back from a uniform differs from the one which was set.
+func uniformMismatch(name string, value, expected any) error {
+	return fmt.Errorf("uniform %s was not set correctly: %v != %v", name, value, expected)
+}
 
-	if value[0] != vec[0] || value[1] != vec[1] {
-		log.Fatalf("Uniform value was not set correctly: %v != %v", value, vec)
+// With DebugUniforms, read back the uniform values and check them. n is the number of
+// components of one element, and values holds the elements one after the other. Every element
+// of an array is read back through its own location.
+func checkUniform[T float32 | int32 | uint32](
+	sp ShaderProgram,
+	name string,
+	n int,
+	values []T,
+	getUniform func(program uint32, location int32, value *T)) error {
+
+	if !DebugUniforms {
+		return nil
 	}
+	base, first := name, 0
+	if array, index, ok := strings.Cut(name, "["); ok {
+		base, first = array, atoi(strings.TrimSuffix(index, "]"))
+	}
+	for i := 0; i*n < len(values); i++ {
+		element_name := name
+		if len(values) > n {
+			element_name = fmt.Sprintf("%s[%d]", base, first+i)
+		}
+		uniform, ok := sp.uniforms[element_name]
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrUnknownUniform, element_name)
+		}
+		value := make([]T, n)
+		getUniform(sp.program, uniform.location, &value[0])
+
+		expected := values[i*n : (i+1)*n]
+		if !slices.Equal(value, expected) {
+			return uniformMismatch(element_name, value, expected)
+		}
+	}
+	return nil
 }
 
-func (sp ShaderProgram) SetUniform3f(name string, vec [3]float32) {
-	location := int32(sp.GetUniformLocation(name))
-	gl.Uniform3f(location, vec[0], vec[1], vec[2])
+func (sp ShaderProgram) SetUniform1f(name string, x float32) error {
+	location, err := sp.uniformLocation(name, 1, gl.FLOAT)
+	if err != nil {
+		return err
+	}
+	gl.Uniform1f(location, x)
+	return checkUniform(sp, name, 1, []float32{x}, gl.GetUniformfv)
+}
 
-	// read back the uniform value and check it
-	var value [3]float32
-	gl.GetUniformfv(sp.program, location, &value[0])
+func (sp ShaderProgram) SetUniform2f(name string, vec [2]float32) error {
+	location, err := sp.uniformLocation(name, 1, gl.FLOAT_VEC2)
+	if err != nil {
+		return err
+	}
+	gl.Uniform2f(location, vec[0], vec[1])
+	return checkUniform(sp, name, 2, vec[:], gl.GetUniformfv)
+}
 
-	if value[0] != vec[0] || value[1] != vec[1] || value[2] != vec[2] {
-		log.Fatalln("Uniform value was not set correctly")
+func (sp ShaderProgram) SetUniform3f(name string, vec [3]float32) error {
+	location, err := sp.uniformLocation(name, 1, gl.FLOAT_VEC3)
+	if err != nil {
+		return err
 	}
+	gl.Uniform3f(location, vec[0], vec[1], vec[2])
+	return checkUniform(sp, name, 3, vec[:], gl.GetUniformfv)
 }
 
-func (sp ShaderProgram) SetUniform4f(name string, vec [4]float32) {
-	location := int32(sp.GetUniformLocation(name))
+func (sp ShaderProgram) SetUniform4f(name string, vec [4]float32) error {
+	location, err := sp.uniformLocation(name, 1, gl.FLOAT_VEC4)
+	if err != nil {
+		return err
+	}
 	gl.Uniform4f(location, vec[0], vec[1], vec[2], vec[3])
+	return checkUniform(sp, name, 4, vec[:], gl.GetUniformfv)
+}
 
-	// read back the uniform value and check it
-	var value [4]float32
-	gl.GetUniformfv(sp.program, location, &value[0])
+// Set a float array uniform, starting at the element name refers to ("name" is the same as
+// "name[0]"). The array in the shader must have enough elements left. The same goes for the
+// other array setters.
+func (sp ShaderProgram) SetUniform1fv(name string, xs []float32) error {
+	if len(xs) == 0 {
+		return nil
+	}
+	location, err := sp.uniformLocation(name, len(xs), gl.FLOAT)
+	if err != nil {
+		return err
+	}
+	gl.Uniform1fv(location, int32(len(xs)), &xs[0])
+	return checkUniform(sp, name, 1, xs, gl.GetUniformfv)
+}
+
+// Set a vec2 array uniform.
+func (sp ShaderProgram) SetUniform2fv(name string, vecs [][2]float32) error {
+	if len(vecs) == 0 {
+		return nil
+	}
+	location, err := sp.uniformLocation(name, len(vecs), gl.FLOAT_VEC2)
+	if err != nil {
+		return err
+	}
+	gl.Uniform2fv(location, int32(len(vecs)), &vecs[0][0])
+	return checkUniform(sp, name, 2, unsafe.Slice(&vecs[0][0], 2*len(vecs)), gl.GetUniformfv)
+}
+
+// Set a vec3 array uniform.
+func (sp ShaderProgram) SetUniform3fv(name string, vecs [][3]float32) error {
+	if len(vecs) == 0 {
+		return nil
+	}
+	location, err := sp.uniformLocation(name, len(vecs), gl.FLOAT_VEC3)
+	if err != nil {
+		return err
+	}
+	gl.Uniform3fv(location, int32(len(vecs)), &vecs[0][0])
+	return checkUniform(sp, name, 3, unsafe.Slice(&vecs[0][0], 3*len(vecs)), gl.GetUniformfv)
+}
+
+// Set a vec4 array uniform.
+func (sp ShaderProgram) SetUniform4fv(name string, vecs [][4]float32) error {
+	if len(vecs) == 0 {
+		return nil
+	}
+	location, err := sp.uniformLocation(name, len(vecs), gl.FLOAT_VEC4)
+	if err != nil {
+		return err
+	}
+	gl.Uniform4fv(location, int32(len(vecs)), &vecs[0][0])
+	return checkUniform(sp, name, 4, unsafe.Slice(&vecs[0][0], 4*len(vecs)), gl.GetUniformfv)
+}
+
+// The matrix setters take the elements column by column, like GLSL stores them.
+func (sp ShaderProgram) SetUniformMatrix2f(name string, matrix [4]float32) error {
+	location, err := sp.uniformLocation(name, 1, gl.FLOAT_MAT2)
+	if err != nil {
+		return err
+	}
+	gl.UniformMatrix2fv(location, 1, false, &matrix[0])
+	return checkUniform(sp, name, 4, matrix[:], gl.GetUniformfv)
+}
+
+func (sp ShaderProgram) SetUniformMatrix3f(name string, matrix [9]float32) error {
+	location, err := sp.uniformLocation(name, 1, gl.FLOAT_MAT3)
+	if err != nil {
+		return err
+	}
+	gl.UniformMatrix3fv(location, 1, false, &matrix[0])
+	return checkUniform(sp, name, 9, matrix[:], gl.GetUniformfv)
+}
 
-	if value[0] != vec[0] || value[1] != vec[1] || value[2] != vec[2] || value[3] != vec[3] {
-		log.Fatalln("Uniform value was not set correctly")
+func (sp ShaderProgram) SetUniformMatrix4f(name string, matrix [16]float32) error {
+	location, err := sp.uniformLocation(name, 1, gl.FLOAT_MAT4)
+	if err != nil {
+		return err
 	}
+	gl.UniformMatrix4fv(location, 1, false, &matrix[0])
+	return checkUniform(sp, name, 16, matrix[:], gl.GetUniformfv)
 }
 
-func (sp ShaderProgram) SetUniform1i(name string, x int32) {
-	location := int32(sp.GetUniformLocation(name))
+// Set an int uniform. Also works for bools and samplers, but see SetUniformBool and
+// SetUniformSampler.
+func (sp ShaderProgram) SetUniform1i(name string, x int32) error {
+	location, err := sp.uniformLocation(name, 1, gl.INT, gl.BOOL)
+	if err != nil {
+		return err
+	}
 	gl.Uniform1i(location, x)
+	return checkUniform(sp, name, 1, []int32{x}, gl.GetUniformiv)
+}
+
+func (sp ShaderProgram) SetUniform2i(name string, vec [2]int32) error {
+	location, err := sp.uniformLocation(name, 1, gl.INT_VEC2, gl.BOOL_VEC2)
+	if err != nil {
+		return err
+	}
+	gl.Uniform2i(location, vec[0], vec[1])
+	return checkUniform(sp, name, 2, vec[:], gl.GetUniformiv)
+}
+
+func (sp ShaderProgram) SetUniform3i(name string, vec [3]int32) error {
+	location, err := sp.uniformLocation(name, 1, gl.INT_VEC3, gl.BOOL_VEC3)
+	if err != nil {
+		return err
+	}
+	gl.Uniform3i(location, vec[0], vec[1], vec[2])
+	return checkUniform(sp, name, 3, vec[:], gl.GetUniformiv)
+}
+
+func (sp ShaderProgram) SetUniform4i(name string, vec [4]int32) error {
+	location, err := sp.uniformLocation(name, 1, gl.INT_VEC4, gl.BOOL_VEC4)
+	if err != nil {
+		return err
+	}
+	gl.Uniform4i(location, vec[0], vec[1], vec[2], vec[3])
+	return checkUniform(sp, name, 4, vec[:], gl.GetUniformiv)
+}
+
+// Set an int array uniform.
+func (sp ShaderProgram) SetUniform1iv(name string, xs []int32) error {
+	if len(xs) == 0 {
+		return nil
+	}
+	location, err := sp.uniformLocation(name, len(xs), gl.INT)
+	if err != nil {
+		return err
+	}
+	gl.Uniform1iv(location, int32(len(xs)), &xs[0])
+	return checkUniform(sp, name, 1, xs, gl.GetUniformiv)
+}
+
+func (sp ShaderProgram) SetUniform1ui(name string, x uint32) error {
+	location, err := sp.uniformLocation(name, 1, gl.UNSIGNED_INT)
+	if err != nil {
+		return err
+	}
+	gl.Uniform1ui(location, x)
+	return checkUniform(sp, name, 1, []uint32{x}, gl.GetUniformuiv)
+}
+
+func (sp ShaderProgram) SetUniform2ui(name string, vec [2]uint32) error {
+	location, err := sp.uniformLocation(name, 1, gl.UNSIGNED_INT_VEC2)
+	if err != nil {
+		return err
+	}
+	gl.Uniform2ui(location, vec[0], vec[1])
+	return checkUniform(sp, name, 2, vec[:], gl.GetUniformuiv)
+}
 
-	// read back the uniform value and check it
-	var value int32
-	gl.GetUniformiv(sp.program, location, &value)
+func (sp ShaderProgram) SetUniform3ui(name string, vec [3]uint32) error {
+	location, err := sp.uniformLocation(name, 1, gl.UNSIGNED_INT_VEC3)
+	if err != nil {
+		return err
+	}
+	gl.Uniform3ui(location, vec[0], vec[1], vec[2])
+	return checkUniform(sp, name, 3, vec[:], gl.GetUniformuiv)
+}
+
+func (sp ShaderProgram) SetUniform4ui(name string, vec [4]uint32) error {
+	location, err := sp.uniformLocation(name, 1, gl.UNSIGNED_INT_VEC4)
+	if err != nil {
+		return err
+	}
+	gl.Uniform4ui(location, vec[0], vec[1], vec[2], vec[3])
+	return checkUniform(sp, name, 4, vec[:], gl.GetUniformuiv)
+}
 
-	if value != x {
-		log.Fatalln("Uniform value was not set correctly")
+func (sp ShaderProgram) SetUniformBool(name string, b bool) error {
+	location, err := sp.uniformLocation(name, 1, gl.BOOL)
+	if err != nil {
+		return err
+	}
+	x := int32(0)
+	if b {
+		x = 1
+	}
+	gl.Uniform1i(location, x)
+	return checkUniform(sp, name, 1, []int32{x}, gl.GetUniformiv)
+}
+
+// Bind a sampler uniform to a texture unit, 0 for gl.TEXTURE0 and so on.
+func (sp ShaderProgram) SetUniformSampler(name string, unit int32) error {
+	uniform, ok := sp.uniforms[name]
+	if ok {
+		if _, isSampler := samplerTypes[uniform.utype]; !isSampler {
+			return fmt.Errorf("%w: %s is %s, not a sampler", ErrUniformType, name, glslTypeName(uniform.utype))
+		}
+	}
+	location, err := sp.uniformLocation(name, 1, gl.INT)
+	if err != nil {
+		return err
 	}
+	gl.Uniform1i(location, unit)
+	return checkUniform(sp, name, 1, []int32{unit}, gl.GetUniformiv)
 }
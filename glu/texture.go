@@ -0,0 +1,208 @@
+package glu
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"reflect"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// The storage format of a texture, and the format and type of the pixel data which goes in and
+// out of it.
+type TextureFormat struct {
+	Name           string
+	InternalFormat int32
+	Format         uint32
+	Type           uint32
+	// Bytes per texel of the pixel data
+	PixelSize int
+}
+
+var (
+	R8      = TextureFormat{"R8", gl.R8, gl.RED, gl.UNSIGNED_BYTE, 1}
+	RGBA8   = TextureFormat{"RGBA8", gl.RGBA8, gl.RGBA, gl.UNSIGNED_BYTE, 4}
+	R32F    = TextureFormat{"R32F", gl.R32F, gl.RED, gl.FLOAT, 4}
+	RG32F   = TextureFormat{"RG32F", gl.RG32F, gl.RG, gl.FLOAT, 8}
+	RGBA32F = TextureFormat{"RGBA32F", gl.RGBA32F, gl.RGBA, gl.FLOAT, 16}
+	// Read in the shader with a usampler2D
+	R32UI = TextureFormat{"R32UI", gl.R32UI, gl.RED_INTEGER, gl.UNSIGNED_INT, 4}
+	// Depth buffers, see FramebufferLayout
+	Depth24  = TextureFormat{"DEPTH24", gl.DEPTH_COMPONENT24, gl.DEPTH_COMPONENT, gl.UNSIGNED_INT, 4}
+	Depth32F = TextureFormat{"DEPTH32F", gl.DEPTH_COMPONENT32F, gl.DEPTH_COMPONENT, gl.FLOAT, 4}
+)
+
+func (f TextureFormat) String() string {
+	return f.Name
+}
+
+// A Texture is a 2D texture. The first row of the pixel data is row 0 of the texture, which is
+// the bottom one in texture coordinates. Images keep their top row first, so they end up upside
+// down unless the texture coordinates flip them back.
+type Texture struct {
+	ID     uint32
+	Width  int32
+	Height int32
+	Format TextureFormat
+}
+
+// Create a texture of the given size and format with undefined contents. It samples the nearest
+// texel and clamps to the edges, see SetFilter and SetWrap.
+func NewTexture(width, height int32, format TextureFormat) *Texture {
+	t := &Texture{Format: format}
+	gl.GenTextures(1, &t.ID)
+	t.SetFilter(gl.NEAREST, gl.NEAREST)
+	t.SetWrap(gl.CLAMP_TO_EDGE, gl.CLAMP_TO_EDGE)
+	t.Resize(width, height)
+	return t
+}
+
+// Create a texture with the contents of an image. Gray images become R8 textures, all others
+// RGBA8.
+func NewTextureFromImage(img image.Image) *Texture {
+	bounds := img.Bounds()
+	var t *Texture
+	var pix []uint8
+	switch img := img.(type) {
+	case *image.Gray:
+		t = NewTexture(int32(bounds.Dx()), int32(bounds.Dy()), R8)
+		gray := image.NewGray(bounds)
+		draw.Draw(gray, bounds, img, bounds.Min, draw.Src)
+		pix = gray.Pix
+	default:
+		t = NewTexture(int32(bounds.Dx()), int32(bounds.Dy()), RGBA8)
+		pix = toRGBA(img).Pix
+	}
+	// Cannot fail, the sizes match
+	_ = t.Upload(pix)
+	return t
+}
+
+// The image as an *image.RGBA with its origin at (0,0) and no padding, copied if needed.
+func toRGBA(img image.Image) *image.RGBA {
+	bounds := img.Bounds()
+	if rgba, ok := img.(*image.RGBA); ok && bounds.Min == (image.Point{}) && rgba.Stride == 4*bounds.Dx() {
+		return rgba
+	}
+	rgba := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), img, bounds.Min, draw.Src)
+	return rgba
+}
+
+// Set the filters used when the texture is minified and magnified, e.g. gl.LINEAR. The
+// mipmap filters, e.g. gl.LINEAR_MIPMAP_LINEAR, need GenerateMipmaps.
+func (t *Texture) SetFilter(min, mag int32) {
+	gl.BindTexture(gl.TEXTURE_2D, t.ID)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, min)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, mag)
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+}
+
+// Set what happens to texture coordinates outside [0, 1], e.g. gl.REPEAT.
+func (t *Texture) SetWrap(s, tw int32) {
+	gl.BindTexture(gl.TEXTURE_2D, t.ID)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, s)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, tw)
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+}
+
+// Compute the mipmaps from the contents, and sample them with trilinear filtering. Call it
+// again after changing the contents.
+func (t *Texture) GenerateMipmaps() {
+	gl.BindTexture(gl.TEXTURE_2D, t.ID)
+	gl.GenerateMipmap(gl.TEXTURE_2D)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR_MIPMAP_LINEAR)
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+}
+
+// Reallocate the texture at a new size. The contents are lost.
+func (t *Texture) Resize(width, height int32) {
+	t.Width = width
+	t.Height = height
+	gl.BindTexture(gl.TEXTURE_2D, t.ID)
+	gl.TexImage2D(gl.TEXTURE_2D, 0, t.Format.InternalFormat, width, height, 0, t.Format.Format, t.Format.Type, nil)
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+}
+
+// Replace all of the contents. See UploadRegion.
+func (t *Texture) Upload(data any) error {
+	return t.UploadRegion(0, 0, t.Width, t.Height, data)
+}
+
+// Replace a rectangle of the contents. data is a slice with the texels of the rectangle row by
+// row, in the pixel format of the texture, e.g. []uint8 for RGBA8 or []float32 for RG32F.
+func (t *Texture) UploadRegion(x, y, width, height int32, data any) error {
+	if x < 0 || y < 0 || x+width > t.Width || y+height > t.Height {
+		return fmt.Errorf("region %dx%d at (%d, %d) is outside the %dx%d texture", width, height, x, y, t.Width, t.Height)
+	}
+	if width == 0 || height == 0 {
+		return nil
+	}
+	if err := t.checkData(data, width, height); err != nil {
+		return err
+	}
+	gl.BindTexture(gl.TEXTURE_2D, t.ID)
+	gl.PixelStorei(gl.UNPACK_ALIGNMENT, 1)
+	gl.TexSubImage2D(gl.TEXTURE_2D, 0, x, y, width, height, t.Format.Format, t.Format.Type, gl.Ptr(data))
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+	return nil
+}
+
+// Check that data is a slice with enough bytes for width x height texels.
+func (t *Texture) checkData(data any, width, height int32) error {
+	v := reflect.ValueOf(data)
+	if v.Kind() != reflect.Slice {
+		return fmt.Errorf("texture data must be a slice, not %T", data)
+	}
+	size := v.Len() * int(v.Type().Elem().Size())
+	if need := int(width) * int(height) * t.Format.PixelSize; size < need {
+		return fmt.Errorf("%d bytes of texture data for %dx%d %v texels, which need %d", size, width, height, t.Format, need)
+	}
+	return nil
+}
+
+// Read all of the contents into data, a slice in the pixel format of the texture like for
+// Upload.
+func (t *Texture) Read(data any) error {
+	if err := t.checkData(data, t.Width, t.Height); err != nil {
+		return err
+	}
+	gl.BindTexture(gl.TEXTURE_2D, t.ID)
+	gl.PixelStorei(gl.PACK_ALIGNMENT, 1)
+	gl.GetTexImage(gl.TEXTURE_2D, 0, t.Format.Format, t.Format.Type, gl.Ptr(data))
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+	return nil
+}
+
+// Read the contents back into an image, row 0 first. Only for R8 and RGBA8 textures, R8 comes
+// out gray.
+func (t *Texture) ReadImage() (*image.RGBA, error) {
+	if t.Format != R8 && t.Format != RGBA8 {
+		return nil, fmt.Errorf("cannot read a %v texture into an image", t.Format)
+	}
+	img := image.NewRGBA(image.Rect(0, 0, int(t.Width), int(t.Height)))
+	gl.BindTexture(gl.TEXTURE_2D, t.ID)
+	gl.PixelStorei(gl.PACK_ALIGNMENT, 1)
+	gl.GetTexImage(gl.TEXTURE_2D, 0, gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(img.Pix))
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+	// R8 comes back as (r, 0, 0, 1)
+	if t.Format == R8 {
+		for i := 0; i < len(img.Pix); i += 4 {
+			img.Pix[i+1], img.Pix[i+2] = img.Pix[i], img.Pix[i]
+		}
+	}
+	return img, nil
+}
+
+// Bind the texture to a texture unit, 0 for gl.TEXTURE0 and so on. The active texture unit is
+// left at 0.
+func (t *Texture) Bind(unit int32) {
+	gl.ActiveTexture(gl.TEXTURE0 + uint32(unit))
+	gl.BindTexture(gl.TEXTURE_2D, t.ID)
+	gl.ActiveTexture(gl.TEXTURE0)
+}
+
+func (t *Texture) Delete() {
+	gl.DeleteTextures(1, &t.ID)
+}
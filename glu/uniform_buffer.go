@@ -0,0 +1,218 @@
+package glu
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"reflect"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// Matrices for uniform blocks, column by column like GLSL stores them.
+type Mat2 [4]float32
+type Mat3 [9]float32
+type Mat4 [16]float32
+
+var IdentityMat3 = Mat3{
+	1, 0, 0,
+	0, 1, 0,
+	0, 0, 1,
+}
+
+// Determinant of the matrix. Zero when it has no inverse.
+func (m Mat3) Determinant() float32 {
+	return m[0]*(m[4]*m[8]-m[7]*m[5]) -
+		m[3]*(m[1]*m[8]-m[7]*m[2]) +
+		m[6]*(m[1]*m[5]-m[4]*m[2])
+}
+
+// A UniformBuffer holds the values of a uniform block in a buffer object (UBO), which is shared
+// by every program that uses the block. Value is laid out in the buffer following the std140
+// rules, so the block must be declared with layout(std140) and its members must be in the same
+// order as the fields of T. The Go types map to GLSL types as follows:
+//
+//   - float32, int32, uint32 and bool to float, int, uint and bool
+//   - [2]T, [3]T and [4]T of those to vectors, e.g. [3]float32 to vec3
+//   - Mat2, Mat3 and Mat4 to mat2, mat3 and mat4
+//   - other arrays to arrays, e.g. [8][2]float32 to vec2[8]
+//   - structs to structs
+type UniformBuffer[T any] struct {
+	// The values of the block. Change them in place, then call Upload.
+	Value T
+
+	ubo     uint32
+	binding uint32
+	data    []byte
+}
+
+// Create a uniform buffer and bind it to a uniform buffer binding point. Fails if T has fields
+// which do not fit in a uniform block.
+func NewUniformBuffer[T any](binding uint32) (*UniformBuffer[T], error) {
+	size, _, err := std140Layout(reflect.TypeOf((*T)(nil)).Elem())
+	if err != nil {
+		return nil, err
+	}
+
+	b := &UniformBuffer[T]{binding: binding, data: make([]byte, size)}
+	gl.GenBuffers(1, &b.ubo)
+	gl.BindBuffer(gl.UNIFORM_BUFFER, b.ubo)
+	gl.BufferData(gl.UNIFORM_BUFFER, size, nil, gl.DYNAMIC_DRAW)
+	gl.BindBuffer(gl.UNIFORM_BUFFER, 0)
+	gl.BindBufferBase(gl.UNIFORM_BUFFER, binding, b.ubo)
+	b.Upload()
+	return b, nil
+}
+
+// Make a program read the named uniform block from this buffer. Fails if the program does not
+// have the block or if the block is smaller than Value.
+func (b *UniformBuffer[T]) Attach(program ShaderProgram, blockName string) error {
+	index, err := program.BindUniformBlock(blockName, b.binding)
+	if err != nil {
+		return err
+	}
+	var size int32
+	gl.GetActiveUniformBlockiv(program.program, index, gl.UNIFORM_BLOCK_DATA_SIZE, &size)
+	if int(size) < len(b.data) {
+		return fmt.Errorf("uniform block %s has %d bytes, but %T needs %d", blockName, size, b.Value, len(b.data))
+	}
+	return nil
+}
+
+// Send Value over to the buffer.
+func (b *UniformBuffer[T]) Upload() {
+	std140Encode(b.data, 0, reflect.ValueOf(&b.Value).Elem())
+	gl.BindBuffer(gl.UNIFORM_BUFFER, b.ubo)
+	gl.BufferSubData(gl.UNIFORM_BUFFER, 0, len(b.data), gl.Ptr(b.data))
+	gl.BindBuffer(gl.UNIFORM_BUFFER, 0)
+}
+
+func (b *UniformBuffer[T]) Delete() {
+	gl.DeleteBuffers(1, &b.ubo)
+}
+
+// Bind a uniform block of the program to a uniform buffer binding point. Returns the index of
+// the block.
+func (sp ShaderProgram) BindUniformBlock(name string, binding uint32) (uint32, error) {
+	index := gl.GetUniformBlockIndex(sp.program, gl.Str(name+"\x00"))
+	if index == gl.INVALID_INDEX {
+		return 0, fmt.Errorf("%w: block %s", ErrUnknownUniform, name)
+	}
+	gl.UniformBlockBinding(sp.program, index, binding)
+	return index, nil
+}
+
+// Number of columns (and rows) of the matrix types, 0 for other types
+func matrixSize(t reflect.Type) int {
+	switch t {
+	case reflect.TypeOf(Mat2{}):
+		return 2
+	case reflect.TypeOf(Mat3{}):
+		return 3
+	case reflect.TypeOf(Mat4{}):
+		return 4
+	}
+	return 0
+}
+
+func isScalar(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Float32, reflect.Int32, reflect.Uint32, reflect.Bool:
+		return true
+	}
+	return false
+}
+
+func isVector(t reflect.Type) bool {
+	return t.Kind() == reflect.Array && t.Len() >= 2 && t.Len() <= 4 && isScalar(t.Elem())
+}
+
+func roundUp(n, multiple int) int {
+	return (n + multiple - 1) / multiple * multiple
+}
+
+// Size and alignment of a Go type in a std140 uniform block, see the OpenGL 3.3 specification,
+// section 2.11.4 "Standard Uniform Block Layout".
+func std140Layout(t reflect.Type) (size int, align int, err error) {
+	switch {
+	case isScalar(t):
+		return 4, 4, nil
+	case matrixSize(t) > 0:
+		// Every column is aligned like vec4
+		return matrixSize(t) * 16, 16, nil
+	case isVector(t):
+		// vec3 is aligned like vec4
+		if t.Len() == 2 {
+			return 8, 8, nil
+		}
+		return 4 * t.Len(), 16, nil
+	case t.Kind() == reflect.Array:
+		// The elements of arrays are aligned like vec4
+		elementSize, elementAlign, err := std140Layout(t.Elem())
+		if err != nil {
+			return 0, 0, err
+		}
+		stride := roundUp(elementSize, max(elementAlign, 16))
+		return stride * t.Len(), 16, nil
+	case t.Kind() == reflect.Struct:
+		offset, align := 0, 16
+		for i := 0; i < t.NumField(); i++ {
+			fieldSize, fieldAlign, err := std140Layout(t.Field(i).Type)
+			if err != nil {
+				return 0, 0, fmt.Errorf("%s.%s: %w", t.Name(), t.Field(i).Name, err)
+			}
+			offset = roundUp(offset, fieldAlign) + fieldSize
+			align = max(align, fieldAlign)
+		}
+		return roundUp(offset, align), align, nil
+	}
+	return 0, 0, fmt.Errorf("%v does not fit in a uniform block", t)
+}
+
+// Write a value into buf at offset with the std140 layout. The layout of its type must have
+// been checked with std140Layout.
+func std140Encode(buf []byte, offset int, v reflect.Value) {
+	t := v.Type()
+	switch {
+	case isScalar(t):
+		var bits uint32
+		switch t.Kind() {
+		case reflect.Float32:
+			bits = math.Float32bits(float32(v.Float()))
+		case reflect.Int32:
+			bits = uint32(int32(v.Int()))
+		case reflect.Uint32:
+			bits = uint32(v.Uint())
+		case reflect.Bool:
+			if v.Bool() {
+				bits = 1
+			}
+		}
+		binary.NativeEndian.PutUint32(buf[offset:], bits)
+	case matrixSize(t) > 0:
+		n := matrixSize(t)
+		for column := 0; column < n; column++ {
+			for row := 0; row < n; row++ {
+				binary.NativeEndian.PutUint32(buf[offset+16*column+4*row:], math.Float32bits(float32(v.Index(n*column+row).Float())))
+			}
+		}
+	case isVector(t):
+		for i := 0; i < t.Len(); i++ {
+			std140Encode(buf, offset+4*i, v.Index(i))
+		}
+	case t.Kind() == reflect.Array:
+		elementSize, elementAlign, _ := std140Layout(t.Elem())
+		stride := roundUp(elementSize, max(elementAlign, 16))
+		for i := 0; i < t.Len(); i++ {
+			std140Encode(buf, offset+stride*i, v.Index(i))
+		}
+	case t.Kind() == reflect.Struct:
+		fieldOffset := 0
+		for i := 0; i < t.NumField(); i++ {
+			fieldSize, fieldAlign, _ := std140Layout(t.Field(i).Type)
+			fieldOffset = roundUp(fieldOffset, fieldAlign)
+			std140Encode(buf, offset+fieldOffset, v.Field(i))
+			fieldOffset += fieldSize
+		}
+	}
+}
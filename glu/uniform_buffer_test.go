@@ -0,0 +1,215 @@
+package glu
+
+import (
+	"encoding/binary"
+	"math"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestStd140Layout(t *testing.T) {
+	tests := []struct {
+		value       any
+		size, align int
+	}{
+		{float32(0), 4, 4},
+		{int32(0), 4, 4},
+		{uint32(0), 4, 4},
+		{false, 4, 4},
+		{[2]float32{}, 8, 8},
+		{[3]int32{}, 12, 16},
+		{[4]uint32{}, 16, 16},
+		{Mat2{}, 32, 16},
+		{Mat3{}, 48, 16},
+		{Mat4{}, 64, 16},
+		// Arrays, with every element aligned like vec4
+		{[1]float32{}, 16, 16},
+		{[5]float32{}, 80, 16},
+		{[8][2]float32{}, 128, 16},
+		{[2][3]float32{}, 32, 16},
+		{[2][4]float32{}, 32, 16},
+		{[2]Mat3{}, 96, 16},
+		// Structs, rounded up to vec4
+		{struct{ A float32 }{}, 16, 16},
+		{struct {
+			A float32
+			B [3]float32
+			C float32
+		}{}, 32, 16},
+		{struct {
+			A [3]float32
+			B float32
+		}{}, 16, 16},
+		{struct {
+			A float32
+			S struct{ X, Y float32 }
+			B float32
+		}{}, 48, 16},
+		{[3]struct{ A [2]float32 }{}, 48, 16},
+	}
+	for _, test := range tests {
+		typ := reflect.TypeOf(test.value)
+		size, align, err := std140Layout(typ)
+		if err != nil || size != test.size || align != test.align {
+			t.Errorf("%v: size %d, align %d, error %v, want size %d, align %d", typ, size, align, err, test.size, test.align)
+		}
+	}
+}
+
+func TestStd140LayoutErrors(t *testing.T) {
+	tests := []struct {
+		value any
+		want  string
+	}{
+		{float64(0), "float64 does not fit in a uniform block"},
+		{int(0), "int does not fit in a uniform block"},
+		{[2]float64{}, "float64 does not fit in a uniform block"},
+		{"", "string does not fit in a uniform block"},
+		{struct {
+			A float32
+			B []float32
+		}{}, ".B: []float32 does not fit in a uniform block"},
+	}
+	for _, test := range tests {
+		_, _, err := std140Layout(reflect.TypeOf(test.value))
+		if err == nil || !strings.HasSuffix(err.Error(), test.want) {
+			t.Errorf("%T: got error %v, want %s", test.value, err, test.want)
+		}
+	}
+}
+
+// Encode a value into a buffer of its std140 size.
+func encodeStd140(t *testing.T, value any) []byte {
+	t.Helper()
+	v := reflect.ValueOf(value)
+	size, _, err := std140Layout(v.Type())
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, size)
+	std140Encode(buf, 0, v)
+	return buf
+}
+
+// The 32 bit words of the buffer
+func words(buf []byte) []uint32 {
+	w := make([]uint32, len(buf)/4)
+	for i := range w {
+		w[i] = binary.NativeEndian.Uint32(buf[4*i:])
+	}
+	return w
+}
+
+func bits(x float32) uint32 {
+	return math.Float32bits(x)
+}
+
+func TestStd140Encode(t *testing.T) {
+	tests := []struct {
+		name  string
+		value any
+		want  []uint32
+	}{
+		{"scalars", struct {
+			A float32
+			B int32
+			C uint32
+			D bool
+		}{1.5, -2, 3, true}, []uint32{bits(1.5), 0xfffffffe, 3, 1}},
+		{"vec3 then float", struct {
+			A [3]float32
+			B float32
+		}{[3]float32{1, 2, 3}, 4}, []uint32{bits(1), bits(2), bits(3), bits(4)}},
+		{"float then vec3", struct {
+			A float32
+			B [3]float32
+		}{1, [3]float32{2, 3, 4}}, []uint32{bits(1), 0, 0, 0, bits(2), bits(3), bits(4), 0}},
+		{"float then vec2", struct {
+			A float32
+			B [2]float32
+			C float32
+		}{1, [2]float32{2, 3}, 4}, []uint32{bits(1), 0, bits(2), bits(3), bits(4), 0, 0, 0}},
+		{"mat3 columns", Mat3{1, 2, 3, 4, 5, 6, 7, 8, 9}, []uint32{
+			bits(1), bits(2), bits(3), 0,
+			bits(4), bits(5), bits(6), 0,
+			bits(7), bits(8), bits(9), 0,
+		}},
+		{"mat2 columns", Mat2{1, 2, 3, 4}, []uint32{bits(1), bits(2), 0, 0, bits(3), bits(4), 0, 0}},
+		{"float array stride", [5]float32{1, 2, 3, 4, 5}, []uint32{
+			bits(1), 0, 0, 0,
+			bits(2), 0, 0, 0,
+			bits(3), 0, 0, 0,
+			bits(4), 0, 0, 0,
+			bits(5), 0, 0, 0,
+		}},
+		{"vec2 array stride", [2][2]float32{{1, 2}, {3, 4}}, []uint32{bits(1), bits(2), 0, 0, bits(3), bits(4), 0, 0}},
+		{"nested struct", struct {
+			A float32
+			S struct {
+				X float32
+				Y [2]float32
+			}
+			B int32
+		}{1, struct {
+			X float32
+			Y [2]float32
+		}{2, [2]float32{3, 4}}, 5}, []uint32{
+			bits(1), 0, 0, 0,
+			bits(2), 0, bits(3), bits(4),
+			5, 0, 0, 0,
+		}},
+		{"struct array", [2]struct{ A, B float32 }{{1, 2}, {3, 4}}, []uint32{bits(1), bits(2), 0, 0, bits(3), bits(4), 0, 0}},
+	}
+	for _, test := range tests {
+		if got := words(encodeStd140(t, test.value)); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %x, want %x", test.name, got, test.want)
+		}
+	}
+}
+
+// Globals matches the std140 offsets of the members of the Globals block in the shaders.
+func TestGlobalsLayout(t *testing.T) {
+	globals := Globals{
+		Resolution: [2]float32{1, 2},
+		WindowSize: [2]float32{3, 4},
+		Mouse:      [2]float32{5, 6},
+		Time:       7,
+		Frame:      8,
+		View:       Mat3{9, 10, 11, 12, 13, 14, 15, 16, 17},
+	}
+	want := []uint32{
+		bits(1), bits(2), // vec2 resolution at 0
+		bits(3), bits(4), // vec2 window_size at 8
+		bits(5), bits(6), // vec2 mouse at 16
+		bits(7),                        // float time at 24
+		8,                              // int frame at 28
+		bits(9), bits(10), bits(11), 0, // mat3 view at 32, a vec4 per column
+		bits(12), bits(13), bits(14), 0,
+		bits(15), bits(16), bits(17), 0,
+	}
+	if got := words(encodeStd140(t, globals)); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %x, want %x", got, want)
+	}
+
+	// The block declares the fields in the same order, with the same types
+	glslTypes := map[reflect.Type]string{
+		reflect.TypeOf(float32(0)):   "float",
+		reflect.TypeOf(int32(0)):     "int",
+		reflect.TypeOf([2]float32{}): "vec2",
+		reflect.TypeOf(Mat3{}):       "mat3",
+	}
+	members := regexp.MustCompile(`(?m)^\s+(\w+) (\w+);$`).FindAllStringSubmatch(globalsSource, -1)
+	typ := reflect.TypeOf(globals)
+	if len(members) != typ.NumField() {
+		t.Fatalf("the block has %d members, Globals %d fields", len(members), typ.NumField())
+	}
+	for i, member := range members {
+		field := typ.Field(i)
+		name := strings.ReplaceAll(member[2], "_", "")
+		if glslTypes[field.Type] != member[1] || !strings.EqualFold(field.Name, name) {
+			t.Errorf("member %d of the block is %s %s, the field of Globals %s %v", i, member[1], member[2], field.Name, field.Type)
+		}
+	}
+}
@@ -1,6 +1,8 @@
 package glu
 
 import (
+	"unsafe"
+
 	"github.com/go-gl/gl/v3.3-core/gl"
 )
 
@@ -9,6 +11,22 @@ func Init() {
 	if err != nil {
 		panic(err)
 	}
+	initState()
+}
+
+// Like Init, but the gl functions are looked up with getProcAddr. For contexts which do not
+// come from GLFW, see the headless package.
+func InitWithProcAddrFunc(getProcAddr func(name string) unsafe.Pointer) {
+	err := gl.InitWithProcAddrFunc(getProcAddr)
+	if err != nil {
+		panic(err)
+	}
+	initState()
+}
+
+func initState() {
+	// The program of the previous context, if any, is not bound in this one
+	ForgetCurrentProgram()
 	gl.Enable(gl.BLEND)
 	gl.Enable(gl.SCISSOR_TEST)
 	gl.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
@@ -1,39 +1,182 @@
 package glu
 
 import (
+	"bytes"
 	"fmt"
+	"unsafe"
 
 	"github.com/go-gl/gl/v3.3-core/gl"
 )
 
 type VartexArray struct {
 	Vao uint32
+	// The first vertex buffer, the one BufferData writes to
 	Vbo uint32
+	// All the vertex buffers, in the order of the layouts they were created with
+	Vbos []uint32
+	// The element (index) buffer, see BufferIndices
+	Ebo uint32
+
+	// Shared by the copies of the VartexArray, like the buffers themselves
+	state *vertexArrayState
 }
 
-// Create a Vertex Array Object (VAO) for the given vertices.
-// Returns the VAO ID.
+type vertexArrayState struct {
+	// Usage hint and allocated size in bytes of every vertex buffer
+	usages []BufferUsage
+	sizes  []int
+
+	// Type and number of the indices in the element buffer
+	indexType  uint32
+	indexCount int32
+}
+
+// How often the contents of a buffer change, which lets the driver decide where to keep it.
+type BufferUsage uint32
+
+const (
+	// Set once, drawn many times. The default.
+	StaticDraw BufferUsage = gl.STATIC_DRAW
+	// Changed now and then, drawn many times
+	DynamicDraw BufferUsage = gl.DYNAMIC_DRAW
+	// Changed every time before it is drawn, e.g. every frame
+	StreamDraw BufferUsage = gl.STREAM_DRAW
+)
+
+// When set, every upload to a vertex or element buffer is read back and checked, and a
+// mismatch panics. Slow, for debugging only.
+var DebugBuffers = false
+
+// One attribute of a vertex, see VertexBufferLayout.
+type VertexAttribute struct {
+	// Name of the attribute in the shader. If empty, Location is used instead.
+	Name     string
+	Location uint32
+
+	// Number of components, 1 to 4
+	Size int32
+	// Type of the components in the buffer, e.g. gl.FLOAT or gl.UNSIGNED_BYTE
+	Type uint32
+	// Map integer types to [0, 1] (or [-1, 1] when signed) instead of converting them as they are
+	Normalized bool
+	// Pass integer types to the shader as integers, for int, ivec and uvec attributes
+	Integer bool
+
+	// Offset from the start of the vertex, in bytes
+	Offset int
+}
+
+// The layout of the vertices in one vertex buffer. Several attributes in one buffer are
+// interleaved, separate buffers need a layout each.
+type VertexBufferLayout struct {
+	Attributes []VertexAttribute
+	// Bytes from one vertex to the next. 0 for the end of the last attribute, i.e. no padding.
+	Stride int32
+	// 0 to advance to the next vertex for every vertex, n to advance once every n instances
+	Divisor uint32
+	// 0 for StaticDraw
+	Usage BufferUsage
+}
+
+// Size of the component types, in bytes
+var vertexTypeSizes = map[uint32]int{
+	gl.BYTE:           1,
+	gl.UNSIGNED_BYTE:  1,
+	gl.SHORT:          2,
+	gl.UNSIGNED_SHORT: 2,
+	gl.HALF_FLOAT:     2,
+	gl.INT:            4,
+	gl.UNSIGNED_INT:   4,
+	gl.FLOAT:          4,
+}
+
+// Create a Vertex Array Object (VAO) with a single attribute of size floats at location 0.
 func NewVertexArray(size int32) (va VartexArray) {
+	// Cannot fail, there are no names to look up
+	va, _ = NewVertexArrayWithLayout(ShaderProgram{}, VertexBufferLayout{
+		Attributes: []VertexAttribute{{Location: 0, Size: size, Type: gl.FLOAT}},
+	})
+	return va
+}
+
+// Create a Vertex Array Object (VAO) with a vertex buffer for each of the layouts. The names of
+// the attributes are looked up in program, which can be left empty if all the attributes have a
+// location instead.
+func NewVertexArrayWithLayout(program ShaderProgram, layouts ...VertexBufferLayout) (VartexArray, error) {
+	va := VartexArray{
+		Vbos: make([]uint32, len(layouts)),
+		state: &vertexArrayState{
+			usages: make([]BufferUsage, len(layouts)),
+			sizes:  make([]int, len(layouts)),
+		},
+	}
 	gl.GenVertexArrays(1, &va.Vao)
-	gl.GenBuffers(1, &va.Vbo)
+	if len(layouts) > 0 {
+		gl.GenBuffers(int32(len(layouts)), &va.Vbos[0])
+		va.Vbo = va.Vbos[0]
+	}
+	gl.GenBuffers(1, &va.Ebo)
 
 	// Bind the Vertex Array Object first, then bind and set vertex buffer(s) and attribute pointers()
 	gl.BindVertexArray(va.Vao)
+	defer gl.BindVertexArray(0)
+	defer gl.BindBuffer(gl.ARRAY_BUFFER, 0)
 
-	// copy vertices data into VBO (it needs to be bound first)
-	gl.BindBuffer(gl.ARRAY_BUFFER, va.Vbo)
-	// gl.BufferData(gl.ARRAY_BUFFER, 0, nil, gl.STATIC_DRAW)
+	// The element buffer binding is part of the VAO
+	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, va.Ebo)
 
-	// specify the format of our vertex input
-	stride := int32(size * 4) // 4 bytes per float32
-	gl.EnableVertexAttribArray(0)
-	gl.VertexAttribPointerWithOffset(0, size, gl.FLOAT, false, stride, 0)
-	defer gl.DisableVertexAttribArray(0)
+	for i, layout := range layouts {
+		va.state.usages[i] = layout.Usage
+		if layout.Usage == 0 {
+			va.state.usages[i] = StaticDraw
+		}
+		// the attribute pointers refer to the buffer bound at the time
+		gl.BindBuffer(gl.ARRAY_BUFFER, va.Vbos[i])
+		if err := layout.apply(program); err != nil {
+			va.Delete()
+			return VartexArray{}, err
+		}
+	}
+	return va, nil
+}
 
-	// unbind the VAO (safe practice so we don't accidentally (mis)configure it later)
-	gl.BindVertexArray(0)
+// Point the attributes at the currently bound vertex buffer.
+func (layout VertexBufferLayout) apply(program ShaderProgram) error {
+	stride := layout.Stride
+	if stride == 0 {
+		for _, attribute := range layout.Attributes {
+			stride = max(stride, int32(attribute.Offset+int(attribute.Size)*vertexTypeSizes[attribute.Type]))
+		}
+	}
 
-	return va
+	for _, attribute := range layout.Attributes {
+		if attribute.Size < 1 || attribute.Size > 4 {
+			return fmt.Errorf("vertex attribute %q has %d components", attribute.Name, attribute.Size)
+		}
+		if _, ok := vertexTypeSizes[attribute.Type]; !ok {
+			return fmt.Errorf("vertex attribute %q has an unknown type 0x%x", attribute.Name, attribute.Type)
+		}
+		if attribute.Integer && (attribute.Type == gl.FLOAT || attribute.Type == gl.HALF_FLOAT) {
+			return fmt.Errorf("vertex attribute %q is an integer attribute with float components", attribute.Name)
+		}
+
+		location := attribute.Location
+		if attribute.Name != "" {
+			var err error
+			if location, err = program.GetAttribLocation(attribute.Name); err != nil {
+				return err
+			}
+		}
+
+		gl.EnableVertexAttribArray(location)
+		if attribute.Integer {
+			gl.VertexAttribIPointerWithOffset(location, attribute.Size, attribute.Type, stride, uintptr(attribute.Offset))
+		} else {
+			gl.VertexAttribPointerWithOffset(location, attribute.Size, attribute.Type, attribute.Normalized, stride, uintptr(attribute.Offset))
+		}
+		gl.VertexAttribDivisor(location, layout.Divisor)
+	}
+	return nil
 }
 
 func (va VartexArray) Bind() {
@@ -44,22 +187,115 @@ func (va VartexArray) Unbind() {
 	gl.BindVertexArray(0)
 }
 
-// Send new data to the VAO.
+func (va VartexArray) Delete() {
+	if len(va.Vbos) > 0 {
+		gl.DeleteBuffers(int32(len(va.Vbos)), &va.Vbos[0])
+	}
+	gl.DeleteBuffers(1, &va.Ebo)
+	gl.DeleteVertexArrays(1, &va.Vao)
+}
+
+// Send new data to the first vertex buffer.
 func (va VartexArray) BufferData(vertices []float32) {
-	gl.BindBuffer(gl.ARRAY_BUFFER, va.Vbo)
+	BufferVertices(va, 0, vertices)
+}
+
+// Send new data to one of the vertex buffers of a VAO, by its index in the layouts. T is
+// usually a struct matching the layout, or a basic type for buffers with a single attribute.
+//
+// Dynamic and stream buffers keep their storage when the data fits. The old storage is
+// orphaned first, so that the driver can hand out fresh memory instead of waiting for the draws
+// which still read the old data.
+func BufferVertices[T any](va VartexArray, buffer int, vertices []T) {
+	if len(vertices) == 0 {
+		return
+	}
+	size := len(vertices) * int(unsafe.Sizeof(vertices[0]))
+	data := unsafe.Pointer(&vertices[0])
+	usage := uint32(va.state.usages[buffer])
+
+	gl.BindBuffer(gl.ARRAY_BUFFER, va.Vbos[buffer])
+	defer gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+	if va.state.usages[buffer] != StaticDraw && size <= va.state.sizes[buffer] {
+		gl.BufferData(gl.ARRAY_BUFFER, va.state.sizes[buffer], nil, usage)
+		gl.BufferSubData(gl.ARRAY_BUFFER, 0, size, data)
+	} else {
+		gl.BufferData(gl.ARRAY_BUFFER, size, data, usage)
+		va.state.sizes[buffer] = size
+	}
+	checkBuffer(gl.ARRAY_BUFFER, 0, data, size)
+}
+
+// Overwrite part of a vertex buffer, starting at the vertex with index first. The buffer must
+// already be large enough, see BufferVertices.
+func BufferSubVertices[T any](va VartexArray, buffer int, first int, vertices []T) error {
+	if len(vertices) == 0 {
+		return nil
+	}
+	vertexSize := int(unsafe.Sizeof(vertices[0]))
+	offset, size := first*vertexSize, len(vertices)*vertexSize
+	if offset < 0 || offset+size > va.state.sizes[buffer] {
+		return fmt.Errorf("vertices %d to %d do not fit in vertex buffer %d of %d bytes", first, first+len(vertices), buffer, va.state.sizes[buffer])
+	}
+	data := unsafe.Pointer(&vertices[0])
+
+	gl.BindBuffer(gl.ARRAY_BUFFER, va.Vbos[buffer])
 	defer gl.BindBuffer(gl.ARRAY_BUFFER, 0)
-	gl.BufferData(gl.ARRAY_BUFFER, len(vertices)*4, gl.Ptr(vertices), gl.STATIC_DRAW)
+	gl.BufferSubData(gl.ARRAY_BUFFER, offset, size, data)
+	checkBuffer(gl.ARRAY_BUFFER, offset, data, size)
+	return nil
+}
 
-	// Read the data back to make sure it was written correctly
-	var data []float32 = make([]float32, len(vertices))
-	gl.GetBufferSubData(gl.ARRAY_BUFFER, 0, len(vertices)*4, gl.Ptr(&data[0]))
+// Send new indices to the element buffer, for DrawElements. A zero usage is StaticDraw, as in
+// VertexBufferLayout. The VAO bound before is bound again afterwards.
+func BufferIndices[T uint8 | uint16 | uint32](va VartexArray, indices []T, usage BufferUsage) {
+	switch any(indices).(type) {
+	case []uint8:
+		va.state.indexType = gl.UNSIGNED_BYTE
+	case []uint16:
+		va.state.indexType = gl.UNSIGNED_SHORT
+	case []uint32:
+		va.state.indexType = gl.UNSIGNED_INT
+	}
+	va.state.indexCount = int32(len(indices))
+	if len(indices) == 0 {
+		return
+	}
+	size := len(indices) * int(unsafe.Sizeof(indices[0]))
+	data := unsafe.Pointer(&indices[0])
 
-	// Compare the data
-	for i := 0; i < len(vertices); i++ {
-		if vertices[i] != data[i] {
-			fmt.Printf("vertices[%d] = %f, data[%d] = %f\n", i, vertices[i], i, data[i])
-			panic("vertices != data")
-		}
+	if usage == 0 {
+		usage = StaticDraw
 	}
 
+	// The element buffer binding is part of the VAO state, so go through the VAO
+	var previous int32
+	gl.GetIntegerv(gl.VERTEX_ARRAY_BINDING, &previous)
+	gl.BindVertexArray(va.Vao)
+	defer gl.BindVertexArray(uint32(previous))
+	gl.BufferData(gl.ELEMENT_ARRAY_BUFFER, size, data, uint32(usage))
+	checkBuffer(gl.ELEMENT_ARRAY_BUFFER, 0, data, size)
+}
+
+// Draw the vertices listed in the element buffer. The VAO must be bound.
+func (va VartexArray) DrawElements(mode uint32) {
+	gl.DrawElementsWithOffset(mode, va.state.indexCount, va.state.indexType, 0)
+}
+
+// Draw the vertices listed in the element buffer, instances times. The VAO must be bound.
+func (va VartexArray) DrawElementsInstanced(mode uint32, instances int32) {
+	gl.DrawElementsInstanced(mode, va.state.indexCount, va.state.indexType, nil, instances)
+}
+
+// With DebugBuffers, read back size bytes at offset of the buffer bound to target and compare
+// them to data.
+func checkBuffer(target uint32, offset int, data unsafe.Pointer, size int) {
+	if !DebugBuffers {
+		return
+	}
+	readBack := make([]byte, size)
+	gl.GetBufferSubData(target, offset, size, gl.Ptr(readBack))
+	if expected := unsafe.Slice((*byte)(data), size); !bytes.Equal(readBack, expected) {
+		panic(fmt.Sprintf("buffer 0x%x: the data read back differs from the data written", target))
+	}
 }
@@ -0,0 +1,269 @@
+package glu
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// How often ShaderWatcher.Update looks at the files
+const watchInterval = 250 * time.Millisecond
+
+// A ShaderWatcher keeps a shader program in sync with its source files on disk, for
+// development. The files are polled (see Update), so it works the same everywhere and with any
+// editor.
+type ShaderWatcher struct {
+	vertexPath   string
+	fragmentPath string
+	preprocess   func(path string, source string) (string, error)
+
+	// Watched files (the shaders and the extra files) and their modification times
+	files     []string
+	modTimes  []time.Time
+	lastCheck time.Time
+
+	// The program compiled from the latest sources which compiled
+	Program ShaderProgram
+	// Why the latest sources failed to compile and link, or nil if Program is up to date
+	Err error
+}
+
+// Compile the vertex and fragment shader files into a program and start watching them. The
+// sources go through preprocess (if not nil) before being compiled, together with the path they
+// were read from. The extra files are only watched, they are meant for files which preprocess
+// reads. Fails if the first compilation fails, since there is no previous program to fall back to.
+func NewShaderWatcher(
+	vertexPath string,
+	fragmentPath string,
+	preprocess func(path string, source string) (string, error),
+	extra ...string,
+) (*ShaderWatcher, error) {
+	w := &ShaderWatcher{
+		vertexPath:   vertexPath,
+		fragmentPath: fragmentPath,
+		preprocess:   preprocess,
+		files:        append([]string{vertexPath, fragmentPath}, extra...),
+	}
+	w.modTimes = w.stat()
+	w.lastCheck = time.Now()
+
+	program, err := w.compile()
+	if err != nil {
+		return nil, err
+	}
+	w.Program = program
+	return w, nil
+}
+
+// Modification times of the watched files. Missing files get the zero time, so that they count
+// as changed once they appear again.
+func (w *ShaderWatcher) stat() []time.Time {
+	modTimes := make([]time.Time, len(w.files))
+	for i, file := range w.files {
+		if info, err := os.Stat(file); err == nil {
+			modTimes[i] = info.ModTime()
+		}
+	}
+	return modTimes
+}
+
+func (w *ShaderWatcher) readSource(path string) (string, error) {
+	source, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	if w.preprocess == nil {
+		return string(source), nil
+	}
+	return w.preprocess(path, string(source))
+}
+
+func (w *ShaderWatcher) compile() (ShaderProgram, error) {
+	vertexSource, err := w.readSource(w.vertexPath)
+	if err != nil {
+		return ShaderProgram{}, err
+	}
+	fragmentSource, err := w.readSource(w.fragmentPath)
+	if err != nil {
+		return ShaderProgram{}, err
+	}
+	program, err := NewShaderProgram(vertexSource, fragmentSource)
+	if err != nil {
+		// Say which file it was, the ShaderError only knows the stage
+		if shaderError, ok := err.(*ShaderError); ok {
+			switch shaderError.Stage {
+			case VERTEX_SHADER:
+				return ShaderProgram{}, fmt.Errorf("%s: %w", w.vertexPath, err)
+			case FRAGMENT_SHADER:
+				return ShaderProgram{}, fmt.Errorf("%s: %w", w.fragmentPath, err)
+			}
+		}
+		return ShaderProgram{}, err
+	}
+	return program, nil
+}
+
+// Recompile the program if any of the files changed. Call it once per frame, it only looks at
+// the files every watchInterval. Returns true if Program was replaced, in which case the values
+// of the uniforms have been copied over from the old program, and the old program is deleted.
+// If the new sources fail to compile Program is kept and Err says why.
+func (w *ShaderWatcher) Update() bool {
+	if time.Since(w.lastCheck) < watchInterval {
+		return false
+	}
+	w.lastCheck = time.Now()
+
+	modTimes := w.stat()
+	changed := false
+	for i := range modTimes {
+		if !modTimes[i].Equal(w.modTimes[i]) {
+			changed = true
+		}
+	}
+	if !changed {
+		return false
+	}
+	w.modTimes = modTimes
+
+	program, err := w.compile()
+	w.Err = err
+	if err != nil {
+		return false
+	}
+	copyUniforms(w.Program, program)
+	w.Program.Delete()
+	w.Program = program
+	return true
+}
+
+func (w *ShaderWatcher) Delete() {
+	w.Program.Delete()
+}
+
+// How the values of a uniform type are read and set
+type uniformKind int
+
+const (
+	floatUniform uniformKind = iota
+	intUniform
+	uintUniform
+)
+
+// Number of components of the uniform types which copyUniforms knows about, and their kind.
+// Samplers of every type are set like an int, see samplerTypes.
+var uniformComponents = map[uint32]struct {
+	n    int
+	kind uniformKind
+}{
+	gl.FLOAT:             {1, floatUniform},
+	gl.FLOAT_VEC2:        {2, floatUniform},
+	gl.FLOAT_VEC3:        {3, floatUniform},
+	gl.FLOAT_VEC4:        {4, floatUniform},
+	gl.FLOAT_MAT2:        {4, floatUniform},
+	gl.FLOAT_MAT3:        {9, floatUniform},
+	gl.FLOAT_MAT4:        {16, floatUniform},
+	gl.INT:               {1, intUniform},
+	gl.INT_VEC2:          {2, intUniform},
+	gl.INT_VEC3:          {3, intUniform},
+	gl.INT_VEC4:          {4, intUniform},
+	gl.BOOL:              {1, intUniform},
+	gl.BOOL_VEC2:         {2, intUniform},
+	gl.BOOL_VEC3:         {3, intUniform},
+	gl.BOOL_VEC4:         {4, intUniform},
+	gl.UNSIGNED_INT:      {1, uintUniform},
+	gl.UNSIGNED_INT_VEC2: {2, uintUniform},
+	gl.UNSIGNED_INT_VEC3: {3, uintUniform},
+	gl.UNSIGNED_INT_VEC4: {4, uintUniform},
+}
+
+// Copy the values of the active uniforms of one program to the uniforms with the same name and
+// type in another. Uniforms which are not in both programs are skipped, and so are the types
+// copyUniforms does not know about, which are logged.
+func copyUniforms(from ShaderProgram, to ShaderProgram) {
+	to.Use()
+	for name, uniform := range from.uniforms {
+		// Every array element is in the map twice, skip the "name" alias of "name[0]"
+		if name != uniform.name {
+			continue
+		}
+		target, ok := to.uniforms[name]
+		if !ok || target.utype != uniform.utype {
+			continue
+		}
+		components, known := uniformComponents[uniform.utype]
+		if _, isSampler := samplerTypes[uniform.utype]; isSampler {
+			components.n, components.kind, known = 1, intUniform, true
+		}
+		if !known {
+			log.Printf("glu: the value of uniform %s of %s is lost on reload", name, glslTypeName(uniform.utype))
+			continue
+		}
+		switch components.kind {
+		case intUniform:
+			var value [4]int32
+			gl.GetUniformiv(from.program, uniform.location, &value[0])
+			setUniformiv(target.location, components.n, value)
+		case uintUniform:
+			var value [4]uint32
+			gl.GetUniformuiv(from.program, uniform.location, &value[0])
+			setUniformuiv(target.location, components.n, value)
+		default:
+			var value [16]float32
+			gl.GetUniformfv(from.program, uniform.location, &value[0])
+			setUniformfv(target.location, uniform.utype, components.n, value)
+		}
+	}
+}
+
+func setUniformiv(location int32, n int, value [4]int32) {
+	switch n {
+	case 1:
+		gl.Uniform1iv(location, 1, &value[0])
+	case 2:
+		gl.Uniform2iv(location, 1, &value[0])
+	case 3:
+		gl.Uniform3iv(location, 1, &value[0])
+	case 4:
+		gl.Uniform4iv(location, 1, &value[0])
+	}
+}
+
+func setUniformuiv(location int32, n int, value [4]uint32) {
+	switch n {
+	case 1:
+		gl.Uniform1uiv(location, 1, &value[0])
+	case 2:
+		gl.Uniform2uiv(location, 1, &value[0])
+	case 3:
+		gl.Uniform3uiv(location, 1, &value[0])
+	case 4:
+		gl.Uniform4uiv(location, 1, &value[0])
+	}
+}
+
+func setUniformfv(location int32, utype uint32, n int, value [16]float32) {
+	switch utype {
+	case gl.FLOAT_MAT2:
+		gl.UniformMatrix2fv(location, 1, false, &value[0])
+		return
+	case gl.FLOAT_MAT3:
+		gl.UniformMatrix3fv(location, 1, false, &value[0])
+		return
+	case gl.FLOAT_MAT4:
+		gl.UniformMatrix4fv(location, 1, false, &value[0])
+		return
+	}
+	switch n {
+	case 1:
+		gl.Uniform1fv(location, 1, &value[0])
+	case 2:
+		gl.Uniform2fv(location, 1, &value[0])
+	case 3:
+		gl.Uniform3fv(location, 1, &value[0])
+	case 4:
+		gl.Uniform4fv(location, 1, &value[0])
+	}
+}
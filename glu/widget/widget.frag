@@ -2,7 +2,6 @@
 
 out vec4 color;
 
-uniform vec2 u_resolution;
 uniform vec4 u_color;
 uniform vec2 u_mouse;
 uniform bool u_mouse_down;
@@ -10,8 +9,8 @@ uniform bool u_mouse_over;
 
 void main()
 {
-	vec2 st = gl_FragCoord.xy/u_resolution.xy;
-	vec2 mouse = u_mouse/u_resolution;
+	vec2 st = gl_FragCoord.xy/globals.window_size;
+	vec2 mouse = u_mouse/globals.window_size;
     mouse.y = 1.0 - mouse.y; // flip y-axis
 
 	float mouse_dist = distance(st, mouse);
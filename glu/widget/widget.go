@@ -1,6 +1,7 @@
 package widget
 
 import (
+	"errors"
 	"fmt"
 	"voronoi/glu"
 
@@ -47,50 +48,47 @@ var widgetVertexShader string
 //go:embed widget.frag
 var widgetFragmentShader string
 
-func newWidgetProgram() glu.ShaderProgram {
-	shaders := []glu.Shader{
-		glu.CompileShader(widgetVertexShader, glu.VERTEX_SHADER),
-		glu.CompileShader(widgetFragmentShader, glu.FRAGMENT_SHADER),
-	}
-
-	return glu.LinkShaders(shaders)
+func newWidgetProgram() (glu.ShaderProgram, error) {
+	return glu.NewShaderProgram(widgetVertexShader, glu.WithGlobals(widgetFragmentShader))
 }
 
-func NewWidget() *Widget {
-	program := newWidgetProgram()
+func NewWidget() (*Widget, error) {
+	program, err := newWidgetProgram()
+	if err != nil {
+		return nil, err
+	}
 
 	w := &Widget{program: program}
 
 	w.vertex_array = glu.NewVertexArray(2)
 
 	// w.vertex_array.BufferData(quad_vertices)
-	w.SetColor(1.0, 1.0, 1.0, 1.0)
+	if err := w.SetColor(1.0, 1.0, 1.0, 1.0); err != nil {
+		return nil, err
+	}
 
-	return w
+	return w, nil
 }
 
-func (w *Widget) SetColor(red float32, green float32, blue float32, alpha float32) {
+func (w *Widget) SetColor(red float32, green float32, blue float32, alpha float32) error {
 	w.program.Use()
 	defer w.program.Unuse()
 	color := [4]float32{red, green, blue, alpha}
-	w.program.SetUniform4f("u_color", color)
 	w.color = color
+	return w.program.SetUniform4f("u_color", color)
 }
 
-// Set all the stuff to do with the window size.
+// Set all the stuff to do with the window size. The shader gets the size from
+// glu.Globals.WindowSize, which must be the same.
 func (w *Widget) SetWindow(width int, height int, scale_x float32, scale_y float32) {
 	w.windowWidth = width
 	w.windowHeight = height
 	w.scaleX = scale_x
 	w.scaleY = scale_y
-
-	w.program.Use()
-	defer w.program.Unuse()
-	w.program.SetUniform2f("u_resolution", [2]float32{float32(w.windowWidth), float32(w.windowHeight)})
 }
 
 // Set the mouse position and state.
-func (w *Widget) SetMouse(mouse_x_f64 float64, mouse_y_f64 float64, mouse_down int) {
+func (w *Widget) SetMouse(mouse_x_f64 float64, mouse_y_f64 float64, mouse_down int) error {
 	// Update the previous mouse state
 	w.mouseXPrev = w.mouseX
 	w.mouseYPrev = w.mouseY
@@ -125,16 +123,14 @@ func (w *Widget) SetMouse(mouse_x_f64 float64, mouse_y_f64 float64, mouse_down i
 	// Set the shader uniforms
 	mouse_x := float32(mouse_x_f64 * float64(w.scaleX))
 	mouse_y := float32(mouse_y_f64*float64(w.scaleY)) - float32(w.windowHeight)
-	mouse_over_int := 0
-	if mouse_over {
-		mouse_over_int = 1
-	}
 
 	w.program.Use()
 	defer w.program.Unuse()
-	w.program.SetUniform2f("u_mouse", [2]float32{mouse_x, mouse_y})
-	w.program.SetUniform1i("u_mouse_down", int32(mouse_down))
-	w.program.SetUniform1i("u_mouse_over", int32(mouse_over_int))
+	return errors.Join(
+		w.program.SetUniform2f("u_mouse", [2]float32{mouse_x, mouse_y}),
+		w.program.SetUniformBool("u_mouse_down", w.mouseDown),
+		w.program.SetUniformBool("u_mouse_over", mouse_over),
+	)
 }
 
 func (w *Widget) SetPosition(
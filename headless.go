@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"voronoi/glu"
+	"voronoi/glu/headless"
+)
+
+// Render the diagram of the seeds into a PNG file, without a window. See the glu/headless
+// package for the backends.
+func renderPNG(path string, width, height int32) error {
+	context, err := headless.NewContext()
+	if err != nil {
+		return err
+	}
+	defer context.Destroy()
+
+	glu.InitWithProcAddrFunc(context.GetProcAddr)
+
+	opengl_info := glu.GetOpenGLInfo()
+	fmt.Printf("Headless backend: %s (%s, %s)\n", headless.Backend, opengl_info.Renderer, opengl_info.VersionString)
+
+	target, err := glu.NewFramebuffer(width, height, glu.RGBA8)
+	if err != nil {
+		return err
+	}
+	defer target.Delete()
+
+	globals, err := glu.NewUniformBuffer[glu.Globals](glu.GlobalsBinding)
+	if err != nil {
+		return err
+	}
+	defer globals.Delete()
+	globals.Value.Resolution = [2]float32{float32(width), float32(height)}
+	globals.Value.WindowSize = globals.Value.Resolution
+	globals.Value.View = state.view
+	globals.Upload()
+
+	diagram, err := newDiagramRenderer(*rendererFlag, *shadersFlag, width, height)
+	if err != nil {
+		return err
+	}
+	defer diagram.delete()
+
+	target.Bind()
+	glu.ClearColor(0.0, 0.0, 0.0, 1.0)
+	err = diagram.draw(state.seeds, state.metric, state.p, state.weighting)
+	target.Unbind()
+	if err != nil {
+		return err
+	}
+	img, err := target.ReadImage(0)
+	if err != nil {
+		return err
+	}
+	return glu.SavePNG(path, img)
+}
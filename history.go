@@ -0,0 +1,132 @@
+package main
+
+import "voronoi/geom"
+
+// A seedCommand is an edit of the seeds which can be undone. do applies it to the state, undo
+// reverts it. Both are only called with the seeds as the other one left them.
+type seedCommand interface {
+	do(s *appState)
+	undo(s *appState)
+}
+
+// A seed added at index, the end of the seeds
+type addSeedCommand struct {
+	index int
+	seed  Seed
+}
+
+func (c addSeedCommand) do(s *appState) {
+	s.seeds = append(s.seeds[:c.index], c.seed)
+}
+
+func (c addSeedCommand) undo(s *appState) {
+	s.deleteSeed(c.index)
+}
+
+type moveSeedCommand struct {
+	index    int
+	from, to geom.Point
+}
+
+func (c moveSeedCommand) do(s *appState) {
+	s.moveSeed(c.index, c.to)
+}
+
+func (c moveSeedCommand) undo(s *appState) {
+	s.moveSeed(c.index, c.from)
+}
+
+// The seed at index deleted. seed is the deleted seed, for undo to put back.
+type deleteSeedCommand struct {
+	index int
+	seed  Seed
+}
+
+func (c deleteSeedCommand) do(s *appState) {
+	s.deleteSeed(c.index)
+}
+
+func (c deleteSeedCommand) undo(s *appState) {
+	s.seeds = append(s.seeds[:c.index], append([]Seed{c.seed}, s.seeds[c.index:]...)...)
+}
+
+type recolorSeedCommand struct {
+	index    int
+	from, to [3]float32
+}
+
+func (c recolorSeedCommand) do(s *appState) {
+	s.seeds[c.index].Color = c.to
+}
+
+func (c recolorSeedCommand) undo(s *appState) {
+	s.seeds[c.index].Color = c.from
+}
+
+// All the seeds replaced at once, e.g. by random ones
+type replaceSeedsCommand struct {
+	from, to []Seed
+}
+
+func (c replaceSeedsCommand) do(s *appState) {
+	s.seeds = append([]Seed(nil), c.to...)
+}
+
+func (c replaceSeedsCommand) undo(s *appState) {
+	s.seeds = append([]Seed(nil), c.from...)
+}
+
+// Default number of edits which can be undone, see the -history flag
+const defaultHistoryDepth = 100
+
+// The edits of the seeds, for undo and redo.
+type seedHistory struct {
+	// Edits which can be undone, the latest last
+	done []seedCommand
+	// Edits which were undone and can be redone, the latest undone last. Cleared by a new edit.
+	undone []seedCommand
+	// Maximum length of done. The oldest edits are forgotten beyond that.
+	depth int
+}
+
+// Apply an edit and remember it.
+func (h *seedHistory) execute(s *appState, command seedCommand) {
+	command.do(s)
+	h.record(command)
+}
+
+// Remember an edit which was already applied, e.g. a drag which moved the seed as it went.
+func (h *seedHistory) record(command seedCommand) {
+	h.undone = h.undone[:0]
+	if h.depth <= 0 {
+		return
+	}
+	if len(h.done) >= h.depth {
+		h.done = append(h.done[:0], h.done[len(h.done)-h.depth+1:]...)
+	}
+	h.done = append(h.done, command)
+}
+
+// Undo the latest edit. Returns false if there is none.
+func (h *seedHistory) undo(s *appState) bool {
+	if len(h.done) == 0 {
+		return false
+	}
+	command := h.done[len(h.done)-1]
+	h.done = h.done[:len(h.done)-1]
+	command.undo(s)
+	h.undone = append(h.undone, command)
+	return true
+}
+
+// Redo the latest undone edit. Returns false if there is none.
+func (h *seedHistory) redo(s *appState) bool {
+	if len(h.undone) == 0 {
+		return false
+	}
+	command := h.undone[len(h.undone)-1]
+	h.undone = h.undone[:len(h.undone)-1]
+	command.do(s)
+	h.done = append(h.done, command)
+	return true
+}
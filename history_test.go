@@ -0,0 +1,131 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"voronoi/geom"
+)
+
+func testSeeds() []Seed {
+	return newSeeds([]geom.Point{geom.Pt(0.1, 0.2), geom.Pt(0.5, 0.5), geom.Pt(0.8, 0.3)})
+}
+
+func seedsClone(seeds []Seed) []Seed {
+	return append([]Seed(nil), seeds...)
+}
+
+// Every command does its edit, undo brings the seeds back and redo does it again.
+func TestSeedCommands(t *testing.T) {
+	seeds := testSeeds()
+	added := Seed{Position: geom.Pt(0.9, 0.9), Color: paletteColor(3)}
+	moved := seedsClone(seeds)
+	moved[1].Position = geom.Pt(0.4, 0.6)
+	recolored := seedsClone(seeds)
+	recolored[2].Color = [3]float32{1, 0, 0}
+	replaced := newSeeds([]geom.Point{geom.Pt(0.3, 0.3)})
+
+	tests := []struct {
+		name    string
+		command seedCommand
+		want    []Seed
+	}{
+		{"add", addSeedCommand{index: 3, seed: added}, append(seedsClone(seeds), added)},
+		{"move", moveSeedCommand{index: 1, from: seeds[1].Position, to: geom.Pt(0.4, 0.6)}, moved},
+		{"delete first", deleteSeedCommand{index: 0, seed: seeds[0]}, seedsClone(seeds[1:])},
+		{"delete middle", deleteSeedCommand{index: 1, seed: seeds[1]}, []Seed{seeds[0], seeds[2]}},
+		{"delete last", deleteSeedCommand{index: 2, seed: seeds[2]}, seedsClone(seeds[:2])},
+		{"recolor", recolorSeedCommand{index: 2, from: seeds[2].Color, to: [3]float32{1, 0, 0}}, recolored},
+		{"replace", replaceSeedsCommand{from: seedsClone(seeds), to: replaced}, replaced},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := appState{seeds: testSeeds(), history: seedHistory{depth: defaultHistoryDepth}}
+			s.history.execute(&s, test.command)
+			if !reflect.DeepEqual(s.seeds, test.want) {
+				t.Fatalf("done: %v, want %v", s.seeds, test.want)
+			}
+			if !s.history.undo(&s) || !reflect.DeepEqual(s.seeds, seeds) {
+				t.Fatalf("undone: %v, want %v", s.seeds, seeds)
+			}
+			if !s.history.redo(&s) || !reflect.DeepEqual(s.seeds, test.want) {
+				t.Fatalf("redone: %v, want %v", s.seeds, test.want)
+			}
+			if s.history.redo(&s) {
+				t.Error("redid an edit twice")
+			}
+		})
+	}
+}
+
+// Edits done one after the other are undone in reverse order.
+func TestSeedHistoryOrder(t *testing.T) {
+	s := appState{seeds: testSeeds(), history: seedHistory{depth: defaultHistoryDepth}}
+	var states [][]Seed
+	for _, command := range []seedCommand{
+		deleteSeedCommand{index: 1, seed: s.seeds[1]},
+		addSeedCommand{index: 2, seed: Seed{Position: geom.Pt(0.9, 0.9)}},
+		moveSeedCommand{index: 0, from: s.seeds[0].Position, to: geom.Pt(0, 0)},
+	} {
+		states = append(states, seedsClone(s.seeds))
+		s.history.execute(&s, command)
+	}
+	for i := len(states) - 1; i >= 0; i-- {
+		if !s.history.undo(&s) || !reflect.DeepEqual(s.seeds, states[i]) {
+			t.Fatalf("undo %d: %v, want %v", len(states)-i, s.seeds, states[i])
+		}
+	}
+	if s.history.undo(&s) {
+		t.Error("undid more edits than there were")
+	}
+}
+
+// Only the last depth edits can be undone.
+func TestSeedHistoryDepth(t *testing.T) {
+	for _, depth := range []int{0, 1, 3} {
+		s := appState{seeds: testSeeds(), history: seedHistory{depth: depth}}
+		var positions []geom.Point
+		for i := 0; i < 5; i++ {
+			positions = append(positions, s.seeds[0].Position)
+			s.history.execute(&s, moveSeedCommand{index: 0, from: s.seeds[0].Position, to: geom.Pt(float64(i)/10, 0)})
+		}
+		undone := 0
+		for s.history.undo(&s) {
+			undone++
+			if want := positions[len(positions)-undone]; s.seeds[0].Position != want {
+				t.Errorf("depth %d, undo %d: the seed is at %v, want %v", depth, undone, s.seeds[0].Position, want)
+			}
+		}
+		if undone != depth {
+			t.Errorf("depth %d: undid %d edits", depth, undone)
+		}
+	}
+}
+
+// A new edit, executed or recorded, forgets the edits which were undone.
+func TestSeedHistoryNewEditClearsRedo(t *testing.T) {
+	edits := map[string]func(s *appState){
+		"execute": func(s *appState) {
+			s.history.execute(s, recolorSeedCommand{index: 0, from: s.seeds[0].Color, to: [3]float32{1, 1, 1}})
+		},
+		"record": func(s *appState) {
+			from := s.seeds[0].Position
+			s.moveSeed(0, geom.Pt(0.7, 0.7))
+			s.history.record(moveSeedCommand{index: 0, from: from, to: geom.Pt(0.7, 0.7)})
+		},
+	}
+	for name, edit := range edits {
+		s := appState{seeds: testSeeds(), history: seedHistory{depth: defaultHistoryDepth}}
+		s.history.execute(&s, deleteSeedCommand{index: 2, seed: s.seeds[2]})
+		s.history.undo(&s)
+		edit(&s)
+		if s.history.redo(&s) {
+			t.Errorf("%s: redid an edit undone before the new one", name)
+		}
+		if len(s.seeds) != 3 {
+			t.Errorf("%s: %d seeds, want 3", name, len(s.seeds))
+		}
+		if !s.history.undo(&s) || s.history.undo(&s) {
+			t.Errorf("%s: want exactly the new edit to undo", name)
+		}
+	}
+}
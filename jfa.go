@@ -0,0 +1,204 @@
+package main
+
+import (
+	"errors"
+	"voronoi/geom"
+	"voronoi/glu"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+
+	_ "embed"
+)
+
+// The Jump Flooding Algorithm (JFA) finds the nearest seed of every pixel in O(log n) passes
+// over the pixels, independent of the number of seeds. The seeds first mark the pixels they fall
+// in. Then every pass each pixel looks at its neighbours `step` pixels away and keeps the
+// nearest seed any of them has found, halving the step from pass to pass. The result is
+// approximate, but the errors are rare and small. Seeds which fall in the same pixel are merged,
+// only one of them gets a cell.
+//
+// The passes ping-pong between two float framebuffers, each holding the id of the nearest seed
+// in r (-1 for none) and the distance to it in g.
+
+//go:embed jfa.vert
+var jfaVertexShaderSource string
+
+//go:embed jfa_seed.vert
+var jfaSeedVertexShaderSource string
+
+//go:embed jfa_seed.frag
+var jfaSeedFragmentShaderSource string
+
+//go:embed jfa_step.frag
+var jfaStepFragmentShaderSource string
+
+//go:embed jfa_resolve.frag
+var jfaResolveFragmentShaderSource string
+
+// Texture unit of the id and distance texture. Units 1 and 2 are taken by the seeds.
+const jfaUnit = 3
+
+type jfaRenderer struct {
+	seedProgram    glu.ShaderProgram
+	stepProgram    glu.ShaderProgram
+	resolveProgram glu.ShaderProgram
+
+	buffers [2]*glu.Framebuffer
+	// Index of the buffer with the result of the last pass
+	current int
+
+	// Empty VAO. The passes generate their vertices from gl_VertexID.
+	vao uint32
+}
+
+func newJFARenderer(width, height int32) (*jfaRenderer, error) {
+	r := &jfaRenderer{}
+	var err error
+	if r.seedProgram, err = compileProgram(jfaSeedVertexShaderSource, jfaSeedFragmentShaderSource); err != nil {
+		r.delete()
+		return nil, err
+	}
+	if r.stepProgram, err = compileProgram(jfaVertexShaderSource, jfaStepFragmentShaderSource); err != nil {
+		r.delete()
+		return nil, err
+	}
+	if r.resolveProgram, err = compileProgram(vertexShaderSource, jfaResolveFragmentShaderSource); err != nil {
+		r.delete()
+		return nil, err
+	}
+	gl.GenVertexArrays(1, &r.vao)
+
+	for i := range r.buffers {
+		buffer, err := glu.NewFramebuffer(width, height, glu.RG32F)
+		if err != nil {
+			r.delete()
+			return nil, err
+		}
+		r.buffers[i] = buffer
+	}
+	return r, nil
+}
+
+// Compile and link a vertex and fragment shader, both with the seed code spliced in.
+func compileProgram(vertexSource, fragmentSource string) (glu.ShaderProgram, error) {
+	return glu.NewShaderProgram(shaderSource(vertexSource), shaderSource(fragmentSource))
+}
+
+// Resize the framebuffers to the new window size, in pixels. A minimized window has a size of
+// zero, in which case the old framebuffers are kept.
+func (r *jfaRenderer) resize(width, height int32) error {
+	if width <= 0 || height <= 0 {
+		return nil
+	}
+	for _, buffer := range r.buffers {
+		if err := buffer.Resize(width, height); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// The framebuffer with the id and distance of the nearest seed of every pixel, as found by the
+// last call to flood.
+func (r *jfaRenderer) result() *glu.Framebuffer {
+	return r.buffers[r.current]
+}
+
+// The steps of the passes: half the larger side rounded up to a power of two, halving down to
+// a single pixel, followed by one more single pixel pass (JFA+1) which fixes most of the
+// remaining errors.
+func jfaSteps(width, height int32) []int32 {
+	step := int32(1)
+	for step < max(width, height) {
+		step *= 2
+	}
+	var steps []int32
+	for step /= 2; step >= 1; step /= 2 {
+		steps = append(steps, step)
+	}
+	return append(steps, 1)
+}
+
+// Run the jump flooding passes for the seeds.
+func (r *jfaRenderer) flood(seeds *seedBuffer, metric geom.Metric, p float64, weighting geom.Weighting) error {
+	// The passes write ids, not colors
+	gl.Disable(gl.BLEND)
+	defer gl.Enable(gl.BLEND)
+
+	gl.BindVertexArray(r.vao)
+	defer gl.BindVertexArray(0)
+	seeds.bindTextures()
+
+	// Every seed marks the pixel it falls in, all other pixels have no seed yet
+	r.current = 0
+	target := r.buffers[r.current]
+	target.Bind()
+	gl.ClearColor(-1.0, 0.0, 0.0, 0.0)
+	gl.Clear(gl.COLOR_BUFFER_BIT)
+	if seeds.count == 0 {
+		target.Unbind()
+		return nil
+	}
+	r.seedProgram.Use()
+	if err := r.seedProgram.SetUniformSampler("u_seed_positions", seedPositionsUnit); err != nil {
+		target.Unbind()
+		return err
+	}
+	gl.DrawArrays(gl.POINTS, 0, seeds.count)
+	target.Unbind()
+
+	r.stepProgram.Use()
+	err := errors.Join(
+		r.stepProgram.SetUniformSampler("u_jfa", jfaUnit),
+		r.stepProgram.SetUniformSampler("u_seed_positions", seedPositionsUnit),
+		setMetricUniform(r.stepProgram, metric, p, weighting),
+	)
+	if err != nil {
+		return err
+	}
+	for _, step := range jfaSteps(target.Width, target.Height) {
+		source := r.buffers[r.current]
+		destination := r.buffers[1-r.current]
+
+		source.Texture.Bind(jfaUnit)
+
+		if err := r.stepProgram.SetUniform1i("u_step", step); err != nil {
+			return err
+		}
+		destination.Bind()
+		gl.DrawArrays(gl.TRIANGLES, 0, 3)
+		destination.Unbind()
+
+		r.current = 1 - r.current
+	}
+	return nil
+}
+
+// Use the resolve program, which colors the cells found by flood, and set its uniforms. Like
+// quad.frag it is then drawn with the quad.
+func (r *jfaRenderer) resolve(seeds *seedBuffer, metric geom.Metric, p float64) error {
+	r.resolveProgram.Use()
+	seeds.bindTextures()
+	r.result().Texture.Bind(jfaUnit)
+
+	// The cells are shaded by the plain metric distance, so no u_weighting
+	return errors.Join(
+		r.resolveProgram.SetUniformSampler("u_jfa", jfaUnit),
+		r.resolveProgram.SetUniformSampler("u_seed_positions", seedPositionsUnit),
+		r.resolveProgram.SetUniformSampler("u_seed_colors", seedColorsUnit),
+		r.resolveProgram.SetUniform1i("u_metric", int32(metric)),
+		r.resolveProgram.SetUniform1f("u_p", float32(p)),
+	)
+}
+
+func (r *jfaRenderer) delete() {
+	for _, buffer := range r.buffers {
+		if buffer != nil {
+			buffer.Delete()
+		}
+	}
+	r.seedProgram.Delete()
+	r.stepProgram.Delete()
+	r.resolveProgram.Delete()
+	gl.DeleteVertexArrays(1, &r.vao)
+}
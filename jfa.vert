@@ -0,0 +1,9 @@
+#version 330 core
+
+// A single triangle covering the whole viewport, generated from gl_VertexID. Draw with 3
+// vertices and no attributes.
+void main()
+{
+    vec2 position = vec2((gl_VertexID << 1) & 2, gl_VertexID & 2);
+    gl_Position = vec4(position * 2.0 - 1.0, 0.0, 1.0);
+}
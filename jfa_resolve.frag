@@ -0,0 +1,21 @@
+#version 330 core
+
+// Color the pixels by the nearest seed found by the jump flooding passes, the same way
+// quad.frag does.
+
+uniform sampler2D u_jfa;
+
+out vec4 out_color;
+
+void main()
+{
+    vec2 st = diagramPosition(gl_FragCoord.xy / vec2(textureSize(u_jfa, 0)));
+    float id = texelFetch(u_jfa, ivec2(gl_FragCoord.xy), 0).r;
+
+    vec3 color = vec3(0.0);
+    if (id >= 0.0) {
+        color = shadeCell(st, int(id));
+    }
+
+    out_color = vec4(color, 1.0);
+}
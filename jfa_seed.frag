@@ -0,0 +1,12 @@
+#version 330 core
+
+// Mark the pixel of each seed with its id. The distance is filled in by the first step.
+
+flat in int seed_id;
+
+out vec4 out_jfa;
+
+void main()
+{
+    out_jfa = vec4(float(seed_id), 0.0, 0.0, 0.0);
+}
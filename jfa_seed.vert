@@ -0,0 +1,13 @@
+#version 330 core
+
+// One point per seed, generated from gl_VertexID. Draw with u_seed_count vertices and no
+// attributes. Seeds outside the view are clipped, so their cells are missing.
+
+flat out int seed_id;
+
+void main()
+{
+    seed_id = gl_VertexID;
+    vec2 st = (inverse(globals.view) * vec3(seedPosition(gl_VertexID).xy, 1.0)).xy;
+    gl_Position = vec4(st * 2.0 - 1.0, 0.0, 1.0);
+}
@@ -0,0 +1,39 @@
+#version 330 core
+
+// One pass of the Jump Flooding Algorithm. Every pixel looks at the nearest seeds found so far
+// by its 8 neighbours u_step pixels away (and itself), and keeps the closest one.
+// The texture holds the seed id in r (-1 for none yet) and its distance in g.
+
+uniform sampler2D u_jfa;
+uniform int u_step;
+
+out vec4 out_jfa;
+
+void main()
+{
+    ivec2 size = textureSize(u_jfa, 0);
+    ivec2 texel = ivec2(gl_FragCoord.xy);
+    vec2 st = diagramPosition(gl_FragCoord.xy / vec2(size));
+
+    float m_id = -1.0;
+    float m_dist = 1e20;
+    for (int y = -1; y <= 1; y++) {
+        for (int x = -1; x <= 1; x++) {
+            ivec2 neighbour = texel + ivec2(x, y) * u_step;
+            if (any(lessThan(neighbour, ivec2(0))) || any(greaterThanEqual(neighbour, size))) {
+                continue;
+            }
+            float id = texelFetch(u_jfa, neighbour, 0).r;
+            if (id < 0.0) {
+                continue;
+            }
+            float dist = seedDistance(st, seedPosition(int(id)));
+            if (dist < m_dist) {
+                m_dist = dist;
+                m_id = id;
+            }
+        }
+    }
+
+    out_jfa = vec4(m_id, m_dist, 0.0, 0.0);
+}
@@ -0,0 +1,20 @@
+package main
+
+// The colors of the cells. Seed i gets color i modulo the length of the palette.
+var defaultPalette = [][3]float32{
+	{17.0 / 255.0, 115.0 / 255.0, 185.0 / 255.0},
+	{215.0 / 255.0, 85.0 / 255.0, 38.0 / 255.0},
+	{236.0 / 255.0, 176.0 / 255.0, 53.0 / 255.0},
+	{117.0 / 255.0, 52.0 / 255.0, 137.0 / 255.0},
+	{130.0 / 255.0, 170.0 / 255.0, 69.0 / 255.0},
+}
+
+// The palette new seeds are colored from. Scenes bring their own.
+var palette = defaultPalette
+
+// The seed following the mouse
+var mouseSeedColor = defaultPalette[4]
+
+func paletteColor(i int) [3]float32 {
+	return palette[i%len(palette)]
+}
@@ -2,40 +2,22 @@
 
 out vec4 out_color;
 
-uniform vec2 u_resolution;
-uniform vec2 u_mouse;
-uniform float u_time;
-uniform int u_frame;
-
 float easeInOutCubic(float x);
 vec3 inferno(float t);
 
-vec2 point[5] = vec2[5](
-    vec2(0.83,0.75),
-    vec2(0.60,0.07),
-    vec2(0.28,0.64),
-    vec2(0.31,0.26),
-    vec2(0.5,0.5)
-);
-
-vec3 colors[5] = vec3[5](
-    vec3(17.0/255.0, 115.0/255.0, 185.0/255.0),
-    vec3(215.0/255.0, 85.0/255.0, 38.0/255.0),
-    vec3(236.0/255.0, 176.0/255.0, 53.0/255.0),
-    vec3(117.0/255.0, 52.0/255.0, 137.0/255.0),
-    vec3(130.0/255.0, 170.0/255.0, 69.0/255.0)
-);
+// The seed uniforms and the distance functions come from seeds.glsl, the resolution, mouse and
+// time from the Globals block (see glu.WithGlobals)
 
 void main()
 {
     // dummy vars to make sure the uniforms are used and therefore not optimized away
-    float dummy0 = u_resolution.x;
-    float dummy1 = u_time;
-    float dummy2 = u_mouse.x;
-    float dummy3 = u_frame;
+    float dummy0 = globals.resolution.x;
+    float dummy1 = globals.time;
+    float dummy2 = globals.mouse.x;
+    float dummy3 = globals.frame;
 
-    vec2 st = gl_FragCoord.xy/u_resolution.xy;
-    vec2 mouse = u_mouse/u_resolution;
+    vec2 st = diagramPosition(gl_FragCoord.xy/globals.resolution);
+    vec2 mouse = globals.mouse/globals.resolution;
     mouse.y = 1.0 - mouse.y; // flip y-axis
 
     // Basic voronoi example from:
@@ -43,20 +25,12 @@ void main()
 
     vec3 color = vec3(.0);
 
-    // Cell positions
-    point[4] = mouse;
-
-    float m_dist = 1.;  // minimum distance
-    int m_point = 0;    // index of the closest point
+    float m_dist = 1e20; // minimum distance
+    int m_point = 0;     // index of the closest point
 
     // Iterate through the points positions
-    for (int i = 0; i < 5; i++) {
-        // L1 norm
-        // float dist = abs(st.x-point[i].x) + abs(st.y-point[i].y);
-        // L2 norm
-        float dist = distance(st, point[i]);
-        // L infinite norm
-        // float dist = max(abs(st.x-point[i].x),abs(st.y-point[i].y));
+    for (int i = 0; i < u_seed_count; i++) {
+        float dist = seedDistance(st, seedPosition(i));
 
         // Keep the closer distance
         m_dist = min(m_dist, dist);
@@ -65,9 +39,8 @@ void main()
         }
     }
 
-    // pick a color based on the closest point. sample the inferno colormap
-    color = colors[m_point];
-    color *= 1.0 - m_dist*2.1;
+    // pick a color based on the closest point
+    color = shadeCell(st, m_point);
 
     // Show isolines
     // color -= step(.7,abs(sin(200.0*m_dist)))*.3;
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"voronoi/geom"
+	"voronoi/glu"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// diagramRenderer draws the Voronoi diagram of the seeds with the renderer selected by the
+// -renderer flag. It is shared by the window and by headless rendering.
+type diagramRenderer struct {
+	// quad.frag. Also used by the window for the mouse and time uniforms.
+	shaderProgram glu.ShaderProgram
+	// Keeps shaderProgram in sync with the files, with -shaders. nil otherwise.
+	watcher *glu.ShaderWatcher
+
+	quad  glu.VartexArray
+	seeds *seedBuffer
+
+	// nil with the direct renderer
+	jfa *jfaRenderer
+}
+
+// Create a renderer for a drawing area of the given size, in pixels. If shaderDir is not empty
+// the shaders of the direct renderer are loaded from there instead of the embedded ones.
+func newDiagramRenderer(renderer string, shaderDir string, width, height int32) (*diagramRenderer, error) {
+	d := &diagramRenderer{}
+
+	// the linked shader program determines how the data will be rendered
+	if shaderDir != "" {
+		watcher, err := watchShaders(shaderDir)
+		if err != nil {
+			return nil, err
+		}
+		d.watcher = watcher
+		d.shaderProgram = watcher.Program
+	} else {
+		shaderProgram, err := compileShaders()
+		if err != nil {
+			return nil, err
+		}
+		d.shaderProgram = shaderProgram
+	}
+
+	quad_vertices := []float32{
+		0.9, 0.9,
+		0.9, -0.9,
+		-0.9, 0.9,
+		-0.9, -0.9,
+	}
+
+	d.quad = glu.NewVertexArray(2)
+	d.quad.BufferData(quad_vertices)
+
+	d.seeds = newSeedBuffer()
+
+	if renderer == rendererJFA {
+		jfa, err := newJFARenderer(width, height)
+		if err != nil {
+			d.delete()
+			return nil, err
+		}
+		d.jfa = jfa
+	}
+
+	if err := d.resize(width, height); err != nil {
+		d.delete()
+		return nil, err
+	}
+	return d, nil
+}
+
+// Watch quad.vert, quad.frag and seeds.glsl in dir. Like the embedded shaders, seeds.glsl is
+// spliced into quad.frag.
+func watchShaders(dir string) (*glu.ShaderWatcher, error) {
+	vertexPath := filepath.Join(dir, "quad.vert")
+	fragmentPath := filepath.Join(dir, "quad.frag")
+	seedsPath := filepath.Join(dir, "seeds.glsl")
+	preprocess := func(path string, source string) (string, error) {
+		if path != fragmentPath {
+			return source, nil
+		}
+		seeds, err := os.ReadFile(seedsPath)
+		if err != nil {
+			return "", err
+		}
+		return glu.WithGlobals(spliceSeeds(string(seeds), source)), nil
+	}
+	return glu.NewShaderWatcher(vertexPath, fragmentPath, preprocess, seedsPath)
+}
+
+// Pick up changes to the shader files, with -shaders. Returns why the latest version of the
+// shaders failed to compile, if it did. The last good program is kept in that case.
+func (d *diagramRenderer) reload() error {
+	if d.watcher == nil {
+		return nil
+	}
+	if d.watcher.Update() {
+		d.shaderProgram = d.watcher.Program
+	}
+	return d.watcher.Err
+}
+
+// Set the size of the drawing area, in pixels. The shaders get it from glu.Globals.Resolution,
+// which must be the same.
+func (d *diagramRenderer) resize(width, height int32) error {
+	if d.jfa != nil {
+		return d.jfa.resize(width, height)
+	}
+	return nil
+}
+
+// Draw the diagram of the seeds into the current framebuffer.
+func (d *diagramRenderer) draw(seeds []Seed, metric geom.Metric, p float64, weighting geom.Weighting) error {
+	d.seeds.upload(seeds)
+	if d.jfa != nil {
+		if err := d.jfa.flood(d.seeds, metric, p, weighting); err != nil {
+			return err
+		}
+		if err := d.jfa.resolve(d.seeds, metric, p); err != nil {
+			return err
+		}
+	} else {
+		d.shaderProgram.Use()
+		if err := d.seeds.bind(d.shaderProgram); err != nil {
+			return err
+		}
+		if err := setMetricUniform(d.shaderProgram, metric, p, weighting); err != nil {
+			return err
+		}
+	}
+
+	d.quad.Bind()
+	gl.DrawArrays(gl.TRIANGLE_STRIP, 0, 4)
+	return nil
+}
+
+func (d *diagramRenderer) delete() {
+	if d.jfa != nil {
+		d.jfa.delete()
+	}
+	d.quad.Delete()
+	d.seeds.delete()
+	if d.watcher != nil {
+		d.watcher.Delete()
+	} else {
+		d.shaderProgram.Delete()
+	}
+}
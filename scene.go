@@ -0,0 +1,351 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"voronoi/geom"
+	"voronoi/glu"
+)
+
+// Version of the scene format. Bump it when the meaning of a field changes or the binary layout
+// does; loading refuses scenes from newer versions.
+const sceneVersion = 1
+
+// A scene is everything needed to reproduce what the window shows: the seeds, how their
+// diagram is drawn and how it is looked at. Saved as JSON (.json) or in a compact binary form
+// (.bin), see saveScene. JSON scenes may leave out any field but the version, the missing ones
+// are taken from defaultScene.
+type scene struct {
+	Version int `json:"version"`
+
+	Window sceneWindow `json:"window"`
+	// The view transform, column by column, see glu.Globals.View
+	Camera glu.Mat3 `json:"camera"`
+
+	// Names of the metric and weighting, see geom.ParseMetric and geom.ParseWeighting
+	Metric    string  `json:"metric"`
+	P         float64 `json:"p"`
+	Weighting string  `json:"weighting"`
+
+	Palette [][3]float32 `json:"palette"`
+	Seeds   []sceneSeed  `json:"seeds"`
+
+	// Maps the seeds to the coordinates of the file they were loaded from, see seedFrame
+	Frame sceneFrame `json:"frame"`
+}
+
+type sceneWindow struct {
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+type sceneSeed struct {
+	Position   [2]float64      `json:"position"`
+	Color      [3]float32      `json:"color"`
+	Weight     float64         `json:"weight"`
+	Properties json.RawMessage `json:"properties,omitempty"`
+}
+
+type sceneFrame struct {
+	Origin [2]float64 `json:"origin"`
+	Scale  float64    `json:"scale"`
+	// min x, min y, max x, max y
+	Bounds [4]float64 `json:"bounds"`
+}
+
+func newSceneFrame(f seedFrame) sceneFrame {
+	return sceneFrame{
+		Origin: [2]float64{f.origin.X, f.origin.Y},
+		Scale:  f.scale,
+		Bounds: [4]float64{f.bounds.Min.X, f.bounds.Min.Y, f.bounds.Max.X, f.bounds.Max.Y},
+	}
+}
+
+// Capture the current state as a scene.
+func currentScene() scene {
+	sc := scene{
+		Version:   sceneVersion,
+		Window:    sceneWindow{state.windowWidth, state.windowHeight},
+		Camera:    state.view,
+		Metric:    state.metric.String(),
+		P:         state.p,
+		Weighting: state.weighting.String(),
+		Palette:   slices.Clone(palette),
+		Seeds:     make([]sceneSeed, len(state.seeds)),
+		Frame:     newSceneFrame(state.frame),
+	}
+	for i, seed := range state.seeds {
+		sc.Seeds[i] = sceneSeed{
+			Position:   [2]float64{seed.Position.X, seed.Position.Y},
+			Color:      seed.Color,
+			Weight:     seed.Weight,
+			Properties: seed.Properties,
+		}
+	}
+	return sc
+}
+
+// Make the scene the current state. The replaced seeds can be brought back with undo. Fails,
+// without changing anything, if the scene does not make sense.
+func (sc scene) apply() error {
+	metric, err := geom.ParseMetric(sc.Metric)
+	if err != nil {
+		return err
+	}
+	weighting, err := geom.ParseWeighting(sc.Weighting)
+	if err != nil {
+		return err
+	}
+	if sc.Window.Width <= 0 || sc.Window.Height <= 0 {
+		return fmt.Errorf("window size %dx%d", sc.Window.Width, sc.Window.Height)
+	}
+	if sc.Frame.Scale <= 0 {
+		return fmt.Errorf("frame scale %v", sc.Frame.Scale)
+	}
+	if det := sc.Camera.Determinant(); det == 0 || math.IsNaN(float64(det)) || math.IsInf(float64(det), 0) {
+		return fmt.Errorf("camera %v has no inverse", sc.Camera)
+	}
+
+	seeds := make([]Seed, len(sc.Seeds))
+	for i, seed := range sc.Seeds {
+		seeds[i] = Seed{
+			Position:   geom.Pt(seed.Position[0], seed.Position[1]),
+			Color:      seed.Color,
+			Weight:     seed.Weight,
+			Properties: seed.Properties,
+		}
+	}
+	state.history.execute(&state, replaceSeedsCommand{from: state.seeds, to: seeds})
+	state.metric, state.p, state.weighting = metric, max(sc.P, 1), weighting
+	state.windowWidth, state.windowHeight = sc.Window.Width, sc.Window.Height
+	state.view = sc.Camera
+	state.frame = seedFrame{
+		origin: geom.Pt(sc.Frame.Origin[0], sc.Frame.Origin[1]),
+		scale:  sc.Frame.Scale,
+		bounds: geom.R(sc.Frame.Bounds[0], sc.Frame.Bounds[1], sc.Frame.Bounds[2], sc.Frame.Bounds[3]),
+	}
+	palette = defaultPalette
+	if len(sc.Palette) > 0 {
+		palette = slices.Clone(sc.Palette)
+	}
+	return nil
+}
+
+// The scene of the program started without flags, with no seeds: the default window, the
+// identity camera, the Euclidean metric without weights, the default palette and the unit
+// frame.
+func defaultScene() scene {
+	return scene{
+		Version:   sceneVersion,
+		Window:    sceneWindow{windowWidth, windowHeight},
+		Camera:    glu.IdentityMat3,
+		Metric:    geom.Euclidean.String(),
+		P:         geom.DefaultMinkowskiP,
+		Weighting: geom.Unweighted.String(),
+		Frame:     newSceneFrame(unitFrame),
+	}
+}
+
+// Write a scene to a file, as JSON if its extension is .json and in the binary form if it is
+// .bin.
+func saveScene(path string, sc scene) error {
+	var buf bytes.Buffer
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		encoder := json.NewEncoder(&buf)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(sc); err != nil {
+			return err
+		}
+	case ".bin":
+		if err := sc.writeBinary(&buf); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown scene file type %q, expected .json or .bin", ext)
+	}
+	return os.WriteFile(path, buf.Bytes(), 0o644)
+}
+
+// Read a scene from a file in either form. The binary form is recognized by its magic number,
+// whatever the extension.
+func loadScene(path string) (scene, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return scene{}, err
+	}
+	// JSON scenes may leave out anything but the version
+	sc := defaultScene()
+	sc.Version = 0
+	if bytes.HasPrefix(data, []byte(sceneMagic)) {
+		err = sc.readBinary(bytes.NewReader(data))
+	} else {
+		err = json.Unmarshal(data, &sc)
+	}
+	if err != nil {
+		return scene{}, fmt.Errorf("%s: %w", path, err)
+	}
+	if sc.Version < 1 || sc.Version > sceneVersion {
+		return scene{}, fmt.Errorf("%s: scene version %d, this build reads up to version %d", path, sc.Version, sceneVersion)
+	}
+	return sc, nil
+}
+
+// The binary form starts with this, followed by the version. All numbers are little endian.
+const sceneMagic = "GVSC"
+
+// The fixed size part of the binary form, after the magic number
+type sceneHeader struct {
+	Version       uint32
+	Width, Height int32
+	Camera        glu.Mat3
+	Metric        uint8
+	Weighting     uint8
+	P             float64
+	Origin        [2]float64
+	Scale         float64
+	Bounds        [4]float64
+	PaletteSize   uint32
+	SeedCount     uint32
+}
+
+// A seed in the binary form, followed by the length of its properties and the properties
+type sceneBinarySeed struct {
+	Position       [2]float64
+	Color          [3]float32
+	Weight         float64
+	PropertiesSize uint32
+}
+
+func (sc scene) writeBinary(w io.Writer) error {
+	metric, err := geom.ParseMetric(sc.Metric)
+	if err != nil {
+		return err
+	}
+	weighting, err := geom.ParseWeighting(sc.Weighting)
+	if err != nil {
+		return err
+	}
+
+	// Refuse what readBinary would refuse, so that every saved scene loads
+	if len(sc.Palette) > maxScenePalette || len(sc.Seeds) > maxSceneSeeds {
+		return fmt.Errorf("scene with %d colors and %d seeds, the binary form holds up to %d and %d", len(sc.Palette), len(sc.Seeds), maxScenePalette, maxSceneSeeds)
+	}
+	for i, seed := range sc.Seeds {
+		if len(seed.Properties) > maxSceneProperties {
+			return fmt.Errorf("scene seed %d has %d bytes of properties, the binary form holds up to %d", i, len(seed.Properties), maxSceneProperties)
+		}
+	}
+
+	out := bufio.NewWriter(w)
+	if _, err := out.WriteString(sceneMagic); err != nil {
+		return err
+	}
+	header := sceneHeader{
+		Version:     uint32(sc.Version),
+		Width:       int32(sc.Window.Width),
+		Height:      int32(sc.Window.Height),
+		Camera:      sc.Camera,
+		Metric:      uint8(metric),
+		Weighting:   uint8(weighting),
+		P:           sc.P,
+		Origin:      sc.Frame.Origin,
+		Scale:       sc.Frame.Scale,
+		Bounds:      sc.Frame.Bounds,
+		PaletteSize: uint32(len(sc.Palette)),
+		SeedCount:   uint32(len(sc.Seeds)),
+	}
+	if err := binary.Write(out, binary.LittleEndian, header); err != nil {
+		return err
+	}
+	if err := binary.Write(out, binary.LittleEndian, sc.Palette); err != nil {
+		return err
+	}
+	for _, seed := range sc.Seeds {
+		err := binary.Write(out, binary.LittleEndian, sceneBinarySeed{
+			Position:       seed.Position,
+			Color:          seed.Color,
+			Weight:         seed.Weight,
+			PropertiesSize: uint32(len(seed.Properties)),
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := out.Write(seed.Properties); err != nil {
+			return err
+		}
+	}
+	return out.Flush()
+}
+
+// Largest palette, seed count and properties the binary form is trusted with, so that a
+// corrupt file fails instead of allocating gigabytes
+const (
+	maxScenePalette    = 1 << 16
+	maxSceneSeeds      = 1 << 24
+	maxSceneProperties = 1 << 20
+)
+
+func (sc *scene) readBinary(r io.Reader) error {
+	magic := make([]byte, len(sceneMagic))
+	if _, err := io.ReadFull(r, magic); err != nil {
+		return err
+	}
+	var header sceneHeader
+	if err := binary.Read(r, binary.LittleEndian, &header); err != nil {
+		return fmt.Errorf("scene header: %w", err)
+	}
+	if header.Version > sceneVersion {
+		// The layout of newer versions is unknown, only the version can be trusted
+		sc.Version = int(header.Version)
+		return nil
+	}
+	if header.PaletteSize > maxScenePalette || header.SeedCount > maxSceneSeeds {
+		return fmt.Errorf("scene with %d colors and %d seeds, corrupt?", header.PaletteSize, header.SeedCount)
+	}
+	if geom.Metric(header.Metric) >= geom.NumMetrics || geom.Weighting(header.Weighting) >= geom.NumWeightings {
+		return errors.New("unknown metric or weighting")
+	}
+
+	*sc = scene{
+		Version:   int(header.Version),
+		Window:    sceneWindow{int(header.Width), int(header.Height)},
+		Camera:    header.Camera,
+		Metric:    geom.Metric(header.Metric).String(),
+		P:         header.P,
+		Weighting: geom.Weighting(header.Weighting).String(),
+		Palette:   make([][3]float32, header.PaletteSize),
+		Seeds:     make([]sceneSeed, header.SeedCount),
+		Frame:     sceneFrame{Origin: header.Origin, Scale: header.Scale, Bounds: header.Bounds},
+	}
+	if err := binary.Read(r, binary.LittleEndian, sc.Palette); err != nil {
+		return fmt.Errorf("scene palette: %w", err)
+	}
+	for i := range sc.Seeds {
+		var seed sceneBinarySeed
+		if err := binary.Read(r, binary.LittleEndian, &seed); err != nil {
+			return fmt.Errorf("scene seed %d: %w", i, err)
+		}
+		if seed.PropertiesSize > maxSceneProperties {
+			return fmt.Errorf("scene seed %d has %d bytes of properties, corrupt?", i, seed.PropertiesSize)
+		}
+		sc.Seeds[i] = sceneSeed{Position: seed.Position, Color: seed.Color, Weight: seed.Weight}
+		if seed.PropertiesSize > 0 {
+			sc.Seeds[i].Properties = make(json.RawMessage, seed.PropertiesSize)
+			if _, err := io.ReadFull(r, sc.Seeds[i].Properties); err != nil {
+				return fmt.Errorf("scene seed %d: %w", i, err)
+			}
+		}
+	}
+	return nil
+}
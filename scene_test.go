@@ -0,0 +1,230 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"voronoi/geom"
+	"voronoi/glu"
+)
+
+// Restore the global state and palette which the test changes.
+func keepState(t *testing.T) {
+	saved, savedPalette := state, palette
+	t.Cleanup(func() {
+		state, palette = saved, savedPalette
+	})
+}
+
+func testScene() scene {
+	return scene{
+		Version:   sceneVersion,
+		Window:    sceneWindow{800, 600},
+		Camera:    glu.Mat3{2, 0, 0, 0, 2, 0, -0.5, -0.25, 1},
+		Metric:    geom.Minkowski.String(),
+		P:         2.5,
+		Weighting: geom.Power.String(),
+		Palette:   [][3]float32{{1, 0, 0}, {0, 0.5, 1}},
+		Seeds: []sceneSeed{
+			{Position: [2]float64{0.25, 0.75}, Color: [3]float32{1, 0, 0}, Weight: 0.1, Properties: json.RawMessage(`{"label":"brest"}`)},
+			{Position: [2]float64{0.6, 0.1}, Color: [3]float32{0, 0.5, 1}},
+		},
+		Frame: sceneFrame{Origin: [2]float64{-5, 46}, Scale: 4, Bounds: [4]float64{-5, 47, -1, 49}},
+	}
+}
+
+func TestSceneRoundTrip(t *testing.T) {
+	for _, name := range []string{"scene.json", "scene.bin", "SCENE.JSON"} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name)
+			want := testScene()
+			if err := saveScene(path, want); err != nil {
+				t.Fatal(err)
+			}
+			sc, err := loadScene(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// The JSON form indents the properties
+			for i, seed := range sc.Seeds {
+				if len(seed.Properties) > 0 {
+					var compact bytes.Buffer
+					if err := json.Compact(&compact, seed.Properties); err != nil {
+						t.Fatal(err)
+					}
+					sc.Seeds[i].Properties = compact.Bytes()
+				}
+			}
+			if !reflect.DeepEqual(sc, want) {
+				t.Errorf("got %+v, want %+v", sc, want)
+			}
+		})
+	}
+}
+
+func TestSceneApply(t *testing.T) {
+	keepState(t)
+	before := append([]Seed(nil), state.seeds...)
+	want := testScene()
+	if err := want.apply(); err != nil {
+		t.Fatal(err)
+	}
+	if sc := currentScene(); !reflect.DeepEqual(sc, want) {
+		t.Errorf("applied %+v, got back %+v", want, sc)
+	}
+	if !state.history.undo(&state) || !reflect.DeepEqual(state.seeds, before) {
+		t.Errorf("undo did not bring the seeds back: %v, want %v", state.seeds, before)
+	}
+}
+
+func TestSceneApplyErrors(t *testing.T) {
+	keepState(t)
+	tests := map[string]func(sc *scene){
+		"singular camera":   func(sc *scene) { sc.Camera = glu.Mat3{} },
+		"flat camera":       func(sc *scene) { sc.Camera = glu.Mat3{1, 0, 0, 1, 0, 0, 0, 0, 1} },
+		"unknown metric":    func(sc *scene) { sc.Metric = "hamming" },
+		"unknown weighting": func(sc *scene) { sc.Weighting = "heavy" },
+		"no window":         func(sc *scene) { sc.Window.Height = 0 },
+		"no frame scale":    func(sc *scene) { sc.Frame.Scale = 0 },
+	}
+	for name, change := range tests {
+		before := currentScene()
+		sc := testScene()
+		change(&sc)
+		if err := sc.apply(); err == nil {
+			t.Errorf("%s: applied", name)
+		}
+		if after := currentScene(); !reflect.DeepEqual(after, before) {
+			t.Errorf("%s: the failed scene changed the state", name)
+		}
+	}
+}
+
+// JSON scenes may leave out any field but the version
+func TestLoadSceneDefaults(t *testing.T) {
+	dir := t.TempDir()
+	tests := map[string]func(sc *scene){
+		`{"version": 1}`:                          func(sc *scene) {},
+		`{"version": 1, "p": 1.5}`:                func(sc *scene) { sc.P = 1.5 },
+		`{"version": 1, "frame": {"scale": 2}}`:   func(sc *scene) { sc.Frame.Scale = 2 },
+		`{"version": 1, "window": {"width": 64}}`: func(sc *scene) { sc.Window.Width = 64 },
+		`{"version": 1, "metric": "manhattan", "seeds": [{"position": [0.5, 0.5]}]}`: func(sc *scene) {
+			sc.Metric = "manhattan"
+			sc.Seeds = []sceneSeed{{Position: [2]float64{0.5, 0.5}}}
+		},
+	}
+	for input, change := range tests {
+		path := filepath.Join(dir, "scene.json")
+		if err := os.WriteFile(path, []byte(input), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		sc, err := loadScene(path)
+		if err != nil {
+			t.Fatalf("%s: %v", input, err)
+		}
+		want := defaultScene()
+		change(&want)
+		if !reflect.DeepEqual(sc, want) {
+			t.Errorf("%s: got %+v, want %+v", input, sc, want)
+		}
+
+		keepState(t)
+		if err := sc.apply(); err != nil {
+			t.Errorf("%s: %v", input, err)
+		}
+	}
+}
+
+// The palette of the state and the palette of a scene do not share their colors
+func TestScenePaletteCopied(t *testing.T) {
+	keepState(t)
+	sc := testScene()
+	if err := sc.apply(); err != nil {
+		t.Fatal(err)
+	}
+	sc.Palette[0] = [3]float32{0, 1, 0}
+	if palette[0] != [3]float32{1, 0, 0} {
+		t.Errorf("changing the palette of the applied scene changed the palette to %v", palette)
+	}
+	current := currentScene()
+	current.Palette[1] = [3]float32{0, 1, 0}
+	if palette[1] != [3]float32{0, 0.5, 1} {
+		t.Errorf("changing the palette of the current scene changed the palette to %v", palette)
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestSceneWriteBinaryErrors(t *testing.T) {
+	if err := testScene().writeBinary(failingWriter{}); err == nil || err.Error() != "disk full" {
+		t.Errorf("got error %v, want disk full", err)
+	}
+
+	// What readBinary would refuse is not written
+	sc := testScene()
+	sc.Seeds[1].Properties = json.RawMessage(`"` + strings.Repeat("x", maxSceneProperties) + `"`)
+	var buf bytes.Buffer
+	if err := sc.writeBinary(&buf); err == nil {
+		t.Error("wrote a seed with more properties than can be read back")
+	}
+	sc.Seeds[1].Properties = json.RawMessage(`"` + strings.Repeat("x", maxSceneProperties-2) + `"`)
+	buf.Reset()
+	if err := sc.writeBinary(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var back scene
+	if err := back.readBinary(&buf); err != nil {
+		t.Errorf("the largest properties do not read back: %v", err)
+	}
+}
+
+func TestLoadSceneErrors(t *testing.T) {
+	dir := t.TempDir()
+	binPath := filepath.Join(dir, "scene.bin")
+	if err := saveScene(binPath, testScene()); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(binPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Cut anywhere, the binary form fails to load
+	truncatedPath := filepath.Join(dir, "truncated.bin")
+	for size := len(sceneMagic); size < len(data); size++ {
+		if err := os.WriteFile(truncatedPath, data[:size], 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := loadScene(truncatedPath); err == nil {
+			t.Errorf("loaded a scene cut after %d of %d bytes", size, len(data))
+		}
+	}
+
+	newer := testScene()
+	newer.Version = sceneVersion + 1
+	for _, name := range []string{"newer.json", "newer.bin"} {
+		path := filepath.Join(dir, name)
+		if err := saveScene(path, newer); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := loadScene(path); err == nil || !strings.Contains(err.Error(), "this build reads up to version") {
+			t.Errorf("%s: got error %v, want a version error", name, err)
+		}
+	}
+
+	if err := saveScene(filepath.Join(dir, "scene.txt"), testScene()); err == nil {
+		t.Error("saved a scene into a .txt")
+	}
+	if _, err := loadScene(filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("loaded a missing scene")
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"errors"
+	"voronoi/glu"
+)
+
+// Seeds are streamed to the fragment shader through two float textures with one texel per
+// seed: the position (and weight) in u_seed_positions and the color in u_seed_colors. The
+// texels are laid out row by row, seedTextureWidth per row, so the number of seeds is only
+// limited by the maximum texture size.
+const seedTextureWidth = 1024
+
+// Texture units used by the seed textures. Unit 0 is left for the font.
+const (
+	seedPositionsUnit = 1
+	seedColorsUnit    = 2
+)
+
+type seedBuffer struct {
+	positions *glu.Texture
+	colors    *glu.Texture
+
+	// Number of rows currently allocated in both textures
+	rows int32
+
+	// Number of seeds uploaded by the last call to upload
+	count int32
+
+	// Staging memory, reused between frames
+	position_data []float32
+	color_data    []float32
+}
+
+func newSeedBuffer() *seedBuffer {
+	// The shader reads the texels with texelFetch, so the default nearest filtering is fine
+	return &seedBuffer{
+		positions: glu.NewTexture(seedTextureWidth, 0, glu.RGBA32F),
+		colors:    glu.NewTexture(seedTextureWidth, 0, glu.RGBA32F),
+	}
+}
+
+// Send the seeds over to the textures. The textures only get reallocated when they grow.
+func (b *seedBuffer) upload(seeds []Seed) {
+	b.count = int32(len(seeds))
+	rows := (b.count + seedTextureWidth - 1) / seedTextureWidth
+	if rows == 0 {
+		return
+	}
+
+	size := int(rows * seedTextureWidth * 4)
+	if cap(b.position_data) < size {
+		b.position_data = make([]float32, size)
+		b.color_data = make([]float32, size)
+	}
+	b.position_data = b.position_data[:size]
+	b.color_data = b.color_data[:size]
+	for i, seed := range seeds {
+		b.position_data[4*i+0] = float32(seed.Position.X)
+		b.position_data[4*i+1] = float32(seed.Position.Y)
+		b.position_data[4*i+2] = float32(seed.Weight)
+		b.color_data[4*i+0] = seed.Color[0]
+		b.color_data[4*i+1] = seed.Color[1]
+		b.color_data[4*i+2] = seed.Color[2]
+		b.color_data[4*i+3] = 1.0
+	}
+
+	grow := rows > b.rows
+	if grow {
+		// Grow in powers of two so that adding seeds one by one does not reallocate every frame
+		b.rows = max(b.rows, 1)
+		for b.rows < rows {
+			b.rows *= 2
+		}
+	}
+
+	for _, texture := range []struct {
+		texture *glu.Texture
+		data    []float32
+	}{{b.positions, b.position_data}, {b.colors, b.color_data}} {
+		if grow {
+			texture.texture.Resize(seedTextureWidth, b.rows)
+		}
+		// Cannot fail, the data covers the rows in use and the texture has at least as many
+		_ = texture.texture.UploadRegion(0, 0, seedTextureWidth, rows, texture.data)
+	}
+}
+
+// Bind the textures to their texture units.
+func (b *seedBuffer) bindTextures() {
+	b.positions.Bind(seedPositionsUnit)
+	b.colors.Bind(seedColorsUnit)
+}
+
+// Bind the textures and set all the seed uniforms. We assume that the shader program is already
+// in use.
+func (b *seedBuffer) bind(shaderProgram glu.ShaderProgram) error {
+	b.bindTextures()
+
+	return errors.Join(
+		shaderProgram.SetUniformSampler("u_seed_positions", seedPositionsUnit),
+		shaderProgram.SetUniformSampler("u_seed_colors", seedColorsUnit),
+		shaderProgram.SetUniform1i("u_seed_count", b.count),
+	)
+}
+
+func (b *seedBuffer) delete() {
+	b.positions.Delete()
+	b.colors.Delete()
+}
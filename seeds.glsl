@@ -0,0 +1,80 @@
+// Shared by the Voronoi shaders (quad.frag and the jump flooding ones). It gets spliced in
+// right after their #version line and the Globals block, see shaderSource in voronoi.go.
+
+// Seeds come from Go as float textures with one texel per seed, laid out row by row.
+// See seed_buffer.go.
+uniform sampler2D u_seed_positions;
+uniform sampler2D u_seed_colors;
+uniform int u_seed_count;
+
+// The diagram coordinates of a point of the drawing area, from (0,0) at the bottom left to
+// (1,1) at the top right. The view transform comes from glu.Globals.
+vec2 diagramPosition(vec2 st)
+{
+    return (globals.view * vec3(st, 1.0)).xy;
+}
+
+// Distance metric, selected from Go. Must match geom.Metric.
+#define METRIC_EUCLIDEAN 0
+#define METRIC_MANHATTAN 1
+#define METRIC_CHEBYSHEV 2
+#define METRIC_MINKOWSKI 3
+uniform int u_metric;
+uniform float u_p; // Minkowski p
+
+float metricDistance(vec2 a, vec2 b)
+{
+    vec2 d = abs(a - b);
+    if (u_metric == METRIC_MANHATTAN) {
+        return d.x + d.y;
+    } else if (u_metric == METRIC_CHEBYSHEV) {
+        return max(d.x, d.y);
+    } else if (u_metric == METRIC_MINKOWSKI) {
+        float p = max(u_p, 1.0);
+        return pow(pow(d.x, p) + pow(d.y, p), 1.0 / p);
+    }
+    return length(d);
+}
+
+// Per-seed weights, in the z of u_seed_positions. Must match geom.Weighting.
+// The weight is a radius (see Seed.Weight), so power distances subtract its square.
+#define WEIGHTING_NONE 0
+#define WEIGHTING_POWER 1
+#define WEIGHTING_ADDITIVE 2
+uniform int u_weighting;
+
+float seedDistance(vec2 st, vec3 seed)
+{
+    if (u_weighting == WEIGHTING_POWER) {
+        // Power distances are always Euclidean
+        vec2 d = st - seed.xy;
+        return dot(d, d) - seed.z * seed.z;
+    } else if (u_weighting == WEIGHTING_ADDITIVE) {
+        return metricDistance(st, seed.xy) - seed.z;
+    }
+    return metricDistance(st, seed.xy);
+}
+
+ivec2 seedTexel(int i)
+{
+    int width = textureSize(u_seed_positions, 0).x;
+    return ivec2(i % width, i / width);
+}
+
+// Position (xy) and weight (z) of seed i
+vec3 seedPosition(int i)
+{
+    return texelFetch(u_seed_positions, seedTexel(i), 0).xyz;
+}
+
+vec3 seedColor(int i)
+{
+    return texelFetch(u_seed_colors, seedTexel(i), 0).rgb;
+}
+
+// The color of the cell of seed i at st. Shaded by the plain distance to the seed, since
+// weighted distances can be negative.
+vec3 shadeCell(vec2 st, int i)
+{
+    return seedColor(i) * (1.0 - metricDistance(st, seedPosition(i).xy)*2.1);
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"math"
+	"math/rand"
+	"time"
+	"voronoi/geom"
+	"voronoi/glu"
+)
+
+// Seeds are kept in normalized coordinates: [0, 1] in both directions with y pointing up. This
+// is the same space as `st` in quad.frag, so the CPU diagram matches what is drawn.
+var unitRect = geom.R(0, 0, 1, 1)
+
+// Number of seeds generated by the R key, with the direct and the jump flooding renderer
+const (
+	randomSeedCount    = 500
+	jfaRandomSeedCount = 20000
+)
+
+// A Seed is a site of the Voronoi diagram as the renderer sees it.
+type Seed struct {
+	Position geom.Point
+	Color    [3]float32
+
+	// Weight of the seed in the weighted diagrams. It is a radius in seed coordinates: additive
+	// distances subtract it, power distances subtract its square. See seedWeights.
+	Weight float64
+
+	// Properties of the GeoJSON feature the seed was loaded from, or its label from a CSV or TSV
+	// file, written back with its cell. Empty for other seeds.
+	Properties json.RawMessage
+}
+
+var defaultSeeds = []geom.Point{
+	{X: 0.83, Y: 0.75},
+	{X: 0.60, Y: 0.07},
+	{X: 0.28, Y: 0.64},
+	{X: 0.31, Y: 0.26},
+}
+
+// Make seeds at the given positions, colored from the palette in order.
+func newSeeds(points []geom.Point) []Seed {
+	seeds := make([]Seed, len(points))
+	for i, p := range points {
+		seeds[i] = Seed{Position: p, Color: paletteColor(i)}
+	}
+	return seeds
+}
+
+// The random number generator of randomSeeds, seeded from the clock unless -rand-seed says
+// otherwise
+var seedRand = rand.New(rand.NewSource(time.Now().UnixNano()))
+
+// Random seeds with random weights. The weights are up to half the typical spacing of the
+// seeds so that the weighted cells do not swallow each other completely.
+func randomSeeds(n int) []Seed {
+	points := make([]geom.Point, n)
+	for i := range points {
+		points[i] = geom.Pt(seedRand.Float64(), seedRand.Float64())
+	}
+	seeds := newSeeds(points)
+	spacing := 1.0 / math.Sqrt(float64(max(n, 1)))
+	for i := range seeds {
+		seeds[i].Weight = seedRand.Float64() * spacing / 2
+	}
+	return seeds
+}
+
+func seedPositions(seeds []Seed) []geom.Point {
+	points := make([]geom.Point, len(seeds))
+	for i, seed := range seeds {
+		points[i] = seed.Position
+	}
+	return points
+}
+
+// The weights of the seeds as the geom package expects them for the given weighting.
+func seedWeights(seeds []Seed, weighting geom.Weighting) []float64 {
+	weights := make([]float64, len(seeds))
+	for i, seed := range seeds {
+		weights[i] = seed.Weight
+		if weighting == geom.Power {
+			weights[i] *= seed.Weight
+		}
+	}
+	return weights
+}
+
+// Interactive state of the application, shared between programLoop and the input callbacks.
+type appState struct {
+	seeds []Seed
+	// Maps the seeds to the coordinates of the file they were loaded from
+	frame seedFrame
+	// The edits of the seeds, for undo and redo
+	history seedHistory
+
+	// Size of the window in screen coordinates, and the view transform (the camera), see
+	// glu.Globals.View
+	windowWidth, windowHeight int
+	view                      glu.Mat3
+
+	// Animate Lloyd relaxation of the seeds, one iteration per frame
+	relaxing       bool
+	relaxIteration int
+
+	// Distance metric, and the p of the Minkowski metric
+	metric geom.Metric
+	p      float64
+
+	// How the seed weights affect the distances
+	weighting geom.Weighting
+}
+
+var state = appState{
+	seeds:   newSeeds(defaultSeeds),
+	frame:   unitFrame,
+	history: seedHistory{depth: defaultHistoryDepth},
+	metric:  geom.Euclidean,
+	p:       geom.DefaultMinkowskiP,
+
+	windowWidth:  windowWidth,
+	windowHeight: windowHeight,
+	view:         glu.IdentityMat3,
+}
+
+// Toggle the Lloyd relaxation animation.
+func (s *appState) toggleRelaxing() {
+	s.relaxing = !s.relaxing
+	s.relaxIteration = 0
+}
+
+// Advance the relaxation animation by one iteration. Stops once the seeds no longer move.
+func (s *appState) relaxStep() {
+	if !s.relaxing {
+		return
+	}
+	options := geom.LloydOptions{
+		Metric:    s.metric,
+		P:         s.p,
+		Weighting: s.weighting,
+		Weights:   seedWeights(s.seeds, s.weighting),
+	}
+	points, moved, err := geom.LloydStep(seedPositions(s.seeds), unitRect, options)
+	if err != nil {
+		log.Printf("relax: %v", err)
+		s.relaxing = false
+		return
+	}
+	for i := range s.seeds {
+		s.seeds[i].Position = points[i]
+	}
+	s.relaxIteration++
+	if moved < geom.DefaultLloydTolerance {
+		s.relaxing = false
+	}
+}
+
+// Add a seed at p, with the next color of the palette. Returns its index.
+func (s *appState) addSeed(p geom.Point) int {
+	s.seeds = append(s.seeds, Seed{Position: p, Color: paletteColor(len(s.seeds))})
+	return len(s.seeds) - 1
+}
+
+func (s *appState) moveSeed(i int, p geom.Point) {
+	s.seeds[i].Position = p
+}
+
+// Remove seed i. The seeds after it move down by one.
+func (s *appState) deleteSeed(i int) {
+	s.seeds = append(s.seeds[:i], s.seeds[i+1:]...)
+}
+
+// The seeds to draw this frame: the state's seeds plus, optionally, one following the mouse.
+func (s *appState) frameSeeds(mouse geom.Point, mouse_seed bool) []Seed {
+	if !mouse_seed {
+		return s.seeds
+	}
+	seeds := make([]Seed, len(s.seeds), len(s.seeds)+1)
+	copy(seeds, s.seeds)
+	return append(seeds, Seed{Position: mouse, Color: mouseSeedColor})
+}
+
+// Set the metric and weighting uniforms. We assume that the shader program is already in use.
+func setMetricUniform(shaderProgram glu.ShaderProgram, metric geom.Metric, p float64, weighting geom.Weighting) error {
+	return errors.Join(
+		shaderProgram.SetUniform1i("u_metric", int32(metric)),
+		shaderProgram.SetUniform1f("u_p", float32(p)),
+		shaderProgram.SetUniform1i("u_weighting", int32(weighting)),
+	)
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"voronoi/geom"
+	"voronoi/glu"
+)
+
+// What goes into an SVG export besides the cells
+type svgOptions struct {
+	// A dot at every seed
+	seeds bool
+	// The edges of the Delaunay triangulation of the seeds
+	delaunay bool
+	// The index of every seed next to it
+	labels bool
+}
+
+// Radius of the seed dots and size of the labels, in SVG pixels
+const (
+	svgSeedRadius = 3.0
+	svgLabelSize  = 10.0
+)
+
+// The rectangle of seed coordinates which the window shows through the view transform of the
+// globals. Only scaling and translation are taken into account.
+func viewRect(view glu.Mat3) geom.Rect {
+	// The view maps the drawing area, from (0,0) to (1,1), to seed coordinates
+	return geom.R(
+		float64(view[6]), float64(view[7]),
+		float64(view[0]+view[3]+view[6]), float64(view[1]+view[4]+view[7]),
+	)
+}
+
+// Compute the cells of the seeds under the metric and weighting, clipped to bounds, and write
+// them into an SVG file of the given size in pixels. See writeSVG.
+func exportSVG(path string, seeds []Seed, metric geom.Metric, p float64, weighting geom.Weighting, bounds geom.Rect, width, height int, options svgOptions) error {
+	cells, err := geom.WeightedCells(seedPositions(seeds), seedWeights(seeds, weighting), bounds, weighting, metric, p)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := writeSVG(file, seeds, cells, bounds, width, height, options); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// Write the cells as an SVG image with one polygon per cell, filled with the color of its seed.
+// The distance shading of the shaders is left out. bounds is mapped onto the whole image, with
+// y flipped since SVG y points down.
+func writeSVG(w io.Writer, seeds []Seed, cells [][]geom.Point, bounds geom.Rect, width, height int, options svgOptions) error {
+	toSVG := func(p geom.Point) (float64, float64) {
+		return (p.X - bounds.Min.X) / bounds.Dx() * float64(width), (bounds.Max.Y - p.Y) / bounds.Dy() * float64(height)
+	}
+
+	out := bufio.NewWriter(w)
+	fmt.Fprintf(out, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"%d\" viewBox=\"0 0 %d %d\">\n", width, height, width, height)
+
+	fmt.Fprintln(out, `<g id="cells" stroke-width="0.5" stroke-linejoin="round">`)
+	for i, cell := range cells {
+		if len(cell) == 0 {
+			continue
+		}
+		color := svgColor(seeds[i].Color)
+		fmt.Fprintf(out, `<polygon id="cell-%d" fill="%s" stroke="%s" points="`, i, color, color)
+		for j, point := range cell {
+			if j > 0 {
+				out.WriteByte(' ')
+			}
+			x, y := toSVG(point)
+			fmt.Fprintf(out, "%.2f,%.2f", x, y)
+		}
+		fmt.Fprintln(out, `"/>`)
+	}
+	fmt.Fprintln(out, "</g>")
+
+	if options.delaunay {
+		positions := seedPositions(seeds)
+		fmt.Fprintln(out, `<g id="delaunay" stroke="white" stroke-opacity="0.6" stroke-width="1">`)
+		for _, edge := range geom.NewTriangulation(positions).Edges() {
+			x0, y0 := toSVG(positions[edge[0]])
+			x1, y1 := toSVG(positions[edge[1]])
+			fmt.Fprintf(out, "<line x1=\"%.2f\" y1=\"%.2f\" x2=\"%.2f\" y2=\"%.2f\"/>\n", x0, y0, x1, y1)
+		}
+		fmt.Fprintln(out, "</g>")
+	}
+
+	if options.seeds {
+		fmt.Fprintln(out, `<g id="seeds" fill="black">`)
+		for _, seed := range seeds {
+			if !bounds.Contains(seed.Position) {
+				continue
+			}
+			x, y := toSVG(seed.Position)
+			fmt.Fprintf(out, "<circle cx=\"%.2f\" cy=\"%.2f\" r=\"%g\"/>\n", x, y, svgSeedRadius)
+		}
+		fmt.Fprintln(out, "</g>")
+	}
+
+	if options.labels {
+		fmt.Fprintf(out, "<g id=\"labels\" font-family=\"sans-serif\" font-size=\"%g\" fill=\"black\">\n", svgLabelSize)
+		for i, seed := range seeds {
+			if !bounds.Contains(seed.Position) {
+				continue
+			}
+			x, y := toSVG(seed.Position)
+			fmt.Fprintf(out, "<text x=\"%.2f\" y=\"%.2f\">%d</text>\n", x+svgSeedRadius+1, y-svgSeedRadius-1, i)
+		}
+		fmt.Fprintln(out, "</g>")
+	}
+
+	fmt.Fprintln(out, "</svg>")
+	return out.Flush()
+}
+
+// A seed color as an SVG color, e.g. #1173b9
+func svgColor(color [3]float32) string {
+	channel := func(c float32) int {
+		return int(min(max(c, 0), 1)*255 + 0.5)
+	}
+	return fmt.Sprintf("#%02x%02x%02x", channel(color[0]), channel(color[1]), channel(color[2]))
+}
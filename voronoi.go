@@ -1,9 +1,13 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
 	"log"
 	"runtime"
+	"strings"
+	"voronoi/geom"
 	"voronoi/glu"
 	"voronoi/glu/font"
 	"voronoi/glu/widget"
@@ -18,18 +22,85 @@ import (
 const windowWidth = 800
 const windowHeight = 600
 
+// How much the + and - keys change the p of the Minkowski metric
+const minkowskiPStep = 0.25
+
 //go:embed quad.vert
 var vertexShaderSource string
 
 //go:embed quad.frag
 var fragmentShaderSource string
 
+//go:embed seeds.glsl
+var seedsShaderSource string
+
+// Renderers of the Voronoi diagram, selected with the -renderer flag
+const (
+	// quad.frag loops over all the seeds for every pixel. Exact, but slow beyond a few hundred
+	// seeds.
+	rendererDirect = "direct"
+	// Jump flooding, see jfa.go. Approximate, but fine with tens of thousands of seeds.
+	rendererJFA = "jfa"
+)
+
 func init() {
 	// GLFW event handling must be run on the main OS thread
 	runtime.LockOSThread()
 }
 
 func main() {
+	if err := parseFlags(); err != nil {
+		log.Fatalln(err)
+	}
+
+	if *sceneFlag != "" {
+		// A scene which does not exist yet is created by Ctrl+S
+		sc, err := loadScene(*sceneFlag)
+		if err == nil {
+			err = sc.apply()
+		}
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			log.Fatalln("failed to load the scene:", err)
+		}
+	}
+	if err := applyFlags(); err != nil {
+		log.Fatalln(err)
+	}
+	// Start with an empty history, without the loading of the scene
+	state.history = seedHistory{depth: *historyFlag}
+
+	if *seedsFlag != "" {
+		seeds, frame, err := loadSeeds(*seedsFlag, *columnsFlag)
+		if err != nil {
+			log.Fatalln("failed to load the seeds:", err)
+		}
+		state.seeds, state.frame = seeds, frame
+	}
+	if *countFlag > 0 {
+		state.seeds, state.frame = randomSeeds(*countFlag), unitFrame
+	}
+
+	// Write the files asked for, and exit without opening a window
+	if *cellsFlag != "" {
+		if err := exportCells(*cellsFlag, state.seeds, state.frame, state.metric, state.p, state.weighting); err != nil {
+			log.Fatalln("failed to export the cells:", err)
+		}
+	}
+	if *svgFlag != "" {
+		err := exportSVG(*svgFlag, state.seeds, state.metric, state.p, state.weighting, viewRect(state.view), state.windowWidth, state.windowHeight, svgFlagOptions())
+		if err != nil {
+			log.Fatalln("failed to export SVG:", err)
+		}
+	}
+	if *pngFlag != "" {
+		if err := renderPNG(*pngFlag, int32(state.windowWidth), int32(state.windowHeight)); err != nil {
+			log.Fatalln("failed to render headless:", err)
+		}
+	}
+	if *cellsFlag != "" || *svgFlag != "" || *pngFlag != "" {
+		return
+	}
+
 	if err := glfw.Init(); err != nil {
 		log.Fatalln("failed to inifitialize glfw:", err)
 	}
@@ -40,7 +111,17 @@ func main() {
 	glfw.WindowHint(glfw.ContextVersionMinor, 3)
 	glfw.WindowHint(glfw.OpenGLProfile, glfw.OpenGLCoreProfile)
 	glfw.WindowHint(glfw.OpenGLForwardCompatible, glfw.True)
-	window, err := glfw.CreateWindow(windowWidth, windowHeight, "Hello!", nil, nil)
+
+	// Full screen windows take the resolution of the monitor
+	var monitor *glfw.Monitor
+	width, height := state.windowWidth, state.windowHeight
+	if *fullscreenFlag {
+		monitor = glfw.GetPrimaryMonitor()
+		mode := monitor.GetVideoMode()
+		width, height = mode.Width, mode.Height
+		glfw.WindowHint(glfw.RefreshRate, mode.RefreshRate)
+	}
+	window, err := glfw.CreateWindow(width, height, *titleFlag, monitor, nil)
 	if err != nil {
 		panic(err)
 	}
@@ -63,21 +144,37 @@ func main() {
 	fmt.Println(opengl_info)
 
 	window.SetKeyCallback(keyCallback)
+	window.SetMouseButtonCallback(mouseButtonCallback)
 
-	// Cap the framerate at 60fps
-	glfw.SwapInterval(1)
+	// With vsync, cap the framerate at the refresh rate of the monitor
+	if *vsyncFlag {
+		glfw.SwapInterval(1)
+	} else {
+		glfw.SwapInterval(0)
+	}
 
 	programLoop(window)
 }
 
-func compileShaders() []glu.Shader {
+func compileShaders() (glu.ShaderProgram, error) {
 	if vertexShaderSource == "" || fragmentShaderSource == "" {
 		panic("vertexShaderSource or fragmentShaderSource is empty")
 	}
-	vertexShader := glu.CompileShader(vertexShaderSource, gl.VERTEX_SHADER)
-	fragmentShader := glu.CompileShader(fragmentShaderSource, gl.FRAGMENT_SHADER)
+	return glu.NewShaderProgram(vertexShaderSource, shaderSource(fragmentShaderSource))
+}
 
-	return []glu.Shader{vertexShader, fragmentShader}
+// Splice the Globals block and the seed code of seeds.glsl into the shader source, right after
+// its #version line.
+func shaderSource(source string) string {
+	return glu.WithGlobals(spliceSeeds(seedsShaderSource, source))
+}
+
+// Splice the seed code into the shader source. The #line directives keep the line numbers in
+// the compiler errors pointing into the right file: source string 1 is the seed code, and 0 the
+// shader itself.
+func spliceSeeds(seeds string, source string) string {
+	version, rest, _ := strings.Cut(source, "\n")
+	return version + "\n#line 1 1\n" + seeds + "\n#line 2 0\n" + rest
 }
 
 func programLoop(window *glfw.Window) {
@@ -87,66 +184,77 @@ func programLoop(window *glfw.Window) {
 	monitor := glfw.GetPrimaryMonitor()
 	scale_x, scale_y := monitor.GetContentScale()
 
-	font, err := font.NewFont(dejavusansmono.TTF, 12, scale_x, scale_y)
+	// The state shared by all the shaders, see glu.Globals
+	globals, err := glu.NewUniformBuffer[glu.Globals](glu.GlobalsBinding)
 	if err != nil {
-		log.Panicf("LoadFont: %v", err)
+		log.Panicf("NewUniformBuffer: %v", err)
 	}
+	defer globals.Delete()
 
-	font.UpdateResolution(windowWidth, windowHeight)
-
-	// the linked shader program determines how the data will be rendered
-	shaders := compileShaders()
-	shaderProgram := glu.LinkShaders(shaders)
-	defer shaderProgram.Delete()
-
-	quad_vertices := []float32{
-		0.9, 0.9,
-		0.9, -0.9,
-		-0.9, 0.9,
-		-0.9, -0.9,
+	font, err := font.NewFont(dejavusansmono.TTF, int32(*fontSizeFlag), scale_x, scale_y)
+	if err != nil {
+		log.Panicf("LoadFont: %v", err)
 	}
 
-	quad := glu.NewVertexArray(2)
-	quad.BufferData(quad_vertices)
-	quad.Bind()
-
-	shaderProgram.Use()
-
-	mouse_x, mouse_y := window.GetCursorPos()
-	setMouseUniform(mouse_x, mouse_y, shaderProgram, scale_x, scale_y)
+	// Not the size asked for when full screen
+	window_width, window_height := window.GetSize()
+	diagram, err := newDiagramRenderer(*rendererFlag, *shadersFlag, int32(window_width), int32(window_height))
+	if err != nil {
+		log.Panicf("newDiagramRenderer: %v", err)
+	}
+	defer diagram.delete()
 
 	frame := uint32(0)
-	setTimeUniform(shaderProgram, frame)
 
-	font.SetColor(1.0, 1.0, 1.0, 0.8)
+	if err := font.SetColor(1.0, 1.0, 1.0, 0.8); err != nil {
+		log.Panicf("SetColor: %v", err)
+	}
 
-	widget := widget.NewWidget()
+	widget, err := widget.NewWidget()
+	if err != nil {
+		log.Panicf("NewWidget: %v", err)
+	}
 
 	// Set the window size callback. We do this only now because we need the
 	// callback to capture a bunch of references which we only have after we set everything up.
 	windowSizeCallback := func(window *glfw.Window, width int, height int) {
+		// Minimized windows have a size of zero, which is not worth saving in a scene
+		if width > 0 && height > 0 {
+			state.windowWidth, state.windowHeight = width, height
+		}
 		scale_x, scale_y := window.GetContentScale()
-		gl.Viewport(0, 0, int32(width)*int32(scale_x), int32(height)*int32(scale_y))
-		gl.Scissor(0, 0, int32(width)*int32(scale_x), int32(height)*int32(scale_y))
-		f32_width := float32(float32(width) * scale_x)
-		f32_height := float32(float32(height) * scale_y)
-		shaderProgram.SetUniform2f("u_resolution", [2]float32{f32_width, f32_height})
+		pixel_width, pixel_height := int32(width)*int32(scale_x), int32(height)*int32(scale_y)
+		gl.Viewport(0, 0, pixel_width, pixel_height)
+		gl.Scissor(0, 0, pixel_width, pixel_height)
+		globals.Value.Resolution = [2]float32{float32(pixel_width), float32(pixel_height)}
+		globals.Value.WindowSize = [2]float32{float32(width), float32(height)}
+		globals.Upload()
 		font.UpdateResolution(width, height)
 		widget.SetWindow(width, height, scale_x, scale_y)
+		if err := diagram.resize(pixel_width, pixel_height); err != nil {
+			log.Panicf("resize: %v", err)
+		}
 	}
 
 	window.SetSizeCallback(windowSizeCallback)
-	windowSizeCallback(window, windowWidth, windowHeight)
+	windowSizeCallback(window, window_width, window_height)
 
 	widget.SetPosition(-0.8, -0.8, 200, 300)
 
+	if *recordFlag != "" {
+		if err := windowCapture.startRecording(*recordFlag, 0); err != nil {
+			log.Panicf("record: %v", err)
+		}
+	}
+
 	for !window.ShouldClose() {
 		// poll events and call their registered callbacks
 		glfw.PollEvents()
 		glu.ClearColor(0.0, 0.0, 0.0, 1.0)
 
-		shaderProgram.Use()
-		quad.Bind()
+		// With -shaders, show why the shaders on disk do not compile. The last good ones are
+		// still used.
+		shader_err := diagram.reload()
 
 		// Get current mouse position
 		mouse_x, mouse_y := window.GetCursorPos()
@@ -154,19 +262,65 @@ func programLoop(window *glfw.Window) {
 		// Get whether the mouse button is pressed
 		mouse_button := window.GetMouseButton(glfw.MouseButtonLeft)
 
-		setMouseUniform(mouse_x, mouse_y, shaderProgram, scale_x, scale_y)
-		setTimeUniform(shaderProgram, frame)
-
-		gl.DrawArrays(gl.TRIANGLE_STRIP, 0, 4)
+		setMouseGlobal(&globals.Value, mouse_x, mouse_y, scale_x, scale_y)
+		globals.Value.View = state.view
+		setTimeGlobal(&globals.Value, frame)
+		globals.Value.Time = windowCapture.frameTime(globals.Value.Time)
+		globals.Upload()
+
+		// The mouse seed would just get in the way of the relaxation, and the relaxation would
+		// move the seed being dragged from under the cursor
+		if editor.dragging < 0 {
+			state.relaxStep()
+		}
+		editor.drag(mouseDrawingPosition(window, mouse_x, mouse_y))
+		mouse := mouseSeedPosition(window, mouse_x, mouse_y)
+		seeds := state.frameSeeds(mouse, !state.relaxing && !editor.enabled)
+		if err := diagram.draw(seeds, state.metric, state.p, state.weighting); err != nil {
+			log.Panicf("draw: %v", err)
+		}
+		window_width, window_height := window.GetSize()
+		if err := windowCapture.saveSVG(seeds, viewRect(globals.Value.View), window_width, window_height); err != nil {
+			log.Printf("svg: %v", err)
+		}
 
 		// Draw the text
 		font.Printf(-0.97, 0.97, 1.0, "Mouse: %07.1f, %07.1f Frame: %07v", mouse_x, mouse_y, frame)
-
-		widget.SetMouse(mouse_x, mouse_y, int(mouse_button))
+		if state.metric == geom.Minkowski {
+			font.Printf(-0.97, 0.92, 1.0, "Metric: %v (p = %.2f)", state.metric, state.p)
+		} else {
+			font.Printf(-0.97, 0.92, 1.0, "Metric: %v", state.metric)
+		}
+		if state.weighting != geom.Unweighted {
+			font.Printf(-0.97, 0.87, 1.0, "Weighting: %v", state.weighting)
+		}
+		if state.relaxing {
+			font.Printf(-0.97, 0.82, 1.0, "Lloyd relaxation: iteration %v", state.relaxIteration)
+		}
+		if editor.enabled {
+			font.Printf(-0.97, 0.77, 1.0, "Editing %v seeds: click to add, drag to move, right-click to delete (undo %v, redo %v)",
+				len(state.seeds), len(state.history.done), len(state.history.undone))
+		}
+		if shader_err != nil {
+			font.SetColor(1.0, 0.3, 0.3, 1.0)
+			for i, line := range strings.Split(shader_err.Error(), "\n") {
+				font.Printf(-0.97, 0.72-0.05*float32(i), 1.0, "%s", line)
+			}
+			font.SetColor(1.0, 1.0, 1.0, 0.8)
+		}
+
+		if err := widget.SetMouse(mouse_x, mouse_y, int(mouse_button)); err != nil {
+			log.Panicf("SetMouse: %v", err)
+		}
 
 		// Draw the widget
 		widget.Draw()
 
+		// Save the screenshot and the recorded frame, if any, before the buffer goes away
+		if err := windowCapture.frameDone(int32(globals.Value.Resolution[0]), int32(globals.Value.Resolution[1])); err != nil {
+			log.Printf("capture: %v", err)
+		}
+
 		// Swap in the rendered buffer
 		window.SwapBuffers()
 
@@ -189,28 +343,130 @@ func keyCallback(window *glfw.Window, key glfw.Key, scancode int, action glfw.Ac
 	if key == glfw.KeyEscape && action == glfw.Press {
 		window.SetShouldClose(true)
 	}
+
+	// Tab toggles editing the seeds with the mouse
+	if key == glfw.KeyTab && action == glfw.Press {
+		editor.toggle()
+	}
+
+	// L toggles the Lloyd relaxation animation
+	if key == glfw.KeyL && action == glfw.Press {
+		state.toggleRelaxing()
+	}
+
+	// M cycles through the distance metrics
+	if key == glfw.KeyM && action == glfw.Press {
+		state.metric = state.metric.Next()
+	}
+
+	// W cycles through the weightings of the seeds
+	if key == glfw.KeyW && action == glfw.Press {
+		state.weighting = state.weighting.Next()
+	}
+
+	// + and - change the p of the Minkowski metric
+	if (key == glfw.KeyEqual || key == glfw.KeyKPAdd) && action != glfw.Release {
+		state.p += minkowskiPStep
+	}
+	if (key == glfw.KeyMinus || key == glfw.KeyKPSubtract) && action != glfw.Release {
+		state.p = max(state.p-minkowskiPStep, 1.0)
+	}
+
+	// S saves a screenshot, E exports the diagram as SVG, V starts and stops recording the frames
+	if key == glfw.KeyS && action == glfw.Press && mods&glfw.ModControl == 0 {
+		windowCapture.screenshot = true
+	}
+	if key == glfw.KeyE && action == glfw.Press {
+		windowCapture.svg = true
+	}
+	if key == glfw.KeyV && action == glfw.Press {
+		if err := windowCapture.toggleRecording(float32(glfw.GetTime())); err != nil {
+			log.Printf("record: %v", err)
+		}
+	}
+
+	// R replaces the seeds with random ones. Handy together with L to preview blue noise.
+	if key == glfw.KeyR && action == glfw.Press {
+		n := randomSeedCount
+		if *rendererFlag == rendererJFA {
+			n = jfaRandomSeedCount
+		}
+		if *countFlag > 0 {
+			n = *countFlag
+		}
+		editor.release()
+		state.history.execute(&state, replaceSeedsCommand{from: state.seeds, to: randomSeeds(n)})
+	}
+
+	// C recolors the seed under the cursor while editing
+	if key == glfw.KeyC && action == glfw.Press && editor.enabled {
+		editor.recolor(window)
+	}
+
+	// Ctrl+S saves the scene, Ctrl+O loads it. See -scene.
+	if key == glfw.KeyS && action == glfw.Press && mods&glfw.ModControl != 0 {
+		if err := saveScene(scenePath(), currentScene()); err != nil {
+			log.Printf("save scene: %v", err)
+		} else {
+			fmt.Printf("Saved %s\n", scenePath())
+		}
+	}
+	if key == glfw.KeyO && action == glfw.Press && mods&glfw.ModControl != 0 {
+		editor.release()
+		sc, err := loadScene(scenePath())
+		if err == nil {
+			err = sc.apply()
+		}
+		if err != nil {
+			log.Printf("load scene: %v", err)
+		} else {
+			window.SetSize(state.windowWidth, state.windowHeight)
+		}
+	}
+
+	// Ctrl+Z undoes the last edit of the seeds, Ctrl+Shift+Z redoes it. Held down they repeat.
+	if key == glfw.KeyZ && action != glfw.Release && mods&glfw.ModControl != 0 {
+		editor.release()
+		if mods&glfw.ModShift != 0 {
+			state.history.redo(&state)
+		} else {
+			state.history.undo(&state)
+		}
+	}
 }
 
-// Set the mouse coordinates uniform. We assume that the shader program is already in use.
-func setMouseUniform(
+// Set the mouse coordinates of the globals, in pixels.
+func setMouseGlobal(
+	globals *glu.Globals,
 	mouse_x_f64 float64,
 	mouse_y_f64 float64,
-	shaderProgram glu.ShaderProgram,
 	scale_x float32,
 	scale_y float32,
 ) {
 	mouse_x := float32(mouse_x_f64 * float64(scale_x))
 	mouse_y := float32(mouse_y_f64 * float64(scale_y))
-	shaderProgram.SetUniform2f("u_mouse", [2]float32{mouse_x, mouse_y})
+	globals.Mouse = [2]float32{mouse_x, mouse_y}
+}
+
+// Convert the mouse position from window coordinates to the drawing area, from (0,0) at the
+// bottom left to (1,1) at the top right.
+func mouseDrawingPosition(window *glfw.Window, mouse_x float64, mouse_y float64) geom.Point {
+	width, height := window.GetSize()
+	return geom.Pt(mouse_x/float64(width), 1.0-mouse_y/float64(height))
+}
+
+// Convert the mouse position from window coordinates to seed coordinates, through the view.
+func mouseSeedPosition(window *glfw.Window, mouse_x float64, mouse_y float64) geom.Point {
+	return viewToSeed(state.view, mouseDrawingPosition(window, mouse_x, mouse_y))
 }
 
-func setTimeUniform(shaderProgram glu.ShaderProgram, frame uint32) {
+func setTimeGlobal(globals *glu.Globals, frame uint32) {
 	var time float32
 	if frame == 0 {
 		time = 0.0
 	} else {
 		time = float32(glfw.GetTime())
 	}
-	shaderProgram.SetUniform1f("u_time", time)
-	shaderProgram.SetUniform1i("u_frame", int32(frame))
+	globals.Time = time
+	globals.Frame = int32(frame)
 }
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"voronoi/geom"
+)
+
+// Read the points of a file of WKT (Well-Known Text) geometries: POINT and MULTIPOINT, one or
+// more per line. Empty points are skipped.
+func readWKTPoints(r io.Reader) ([]geom.Point, error) {
+	text, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	p := wktParser{text: string(text)}
+	var points []geom.Point
+	for p.skipSpace(); p.pos < len(p.text); p.skipSpace() {
+		geometry, err := p.geometry()
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", p.line(), err)
+		}
+		points = append(points, geometry...)
+	}
+	return points, nil
+}
+
+type wktParser struct {
+	text string
+	pos  int
+}
+
+func (p *wktParser) line() int {
+	return strings.Count(p.text[:p.pos], "\n") + 1
+}
+
+func (p *wktParser) skipSpace() {
+	for p.pos < len(p.text) && strings.IndexByte(" \t\r\n", p.text[p.pos]) >= 0 {
+		p.pos++
+	}
+}
+
+// Consume the next token if it is s, which must not start with a space.
+func (p *wktParser) accept(s string) bool {
+	p.skipSpace()
+	if strings.HasPrefix(p.text[p.pos:], s) {
+		p.pos += len(s)
+		return true
+	}
+	return false
+}
+
+func (p *wktParser) expect(s string) error {
+	if !p.accept(s) {
+		return fmt.Errorf("expected %q at %q", s, p.rest())
+	}
+	return nil
+}
+
+// The start of the unparsed text, for errors
+func (p *wktParser) rest() string {
+	rest, _, _ := strings.Cut(p.text[p.pos:], "\n")
+	if len(rest) > 20 {
+		rest = rest[:20] + "..."
+	}
+	return rest
+}
+
+func (p *wktParser) word() string {
+	p.skipSpace()
+	start := p.pos
+	for p.pos < len(p.text) && (p.text[p.pos] >= 'A' && p.text[p.pos] <= 'Z' || p.text[p.pos] >= 'a' && p.text[p.pos] <= 'z') {
+		p.pos++
+	}
+	return strings.ToUpper(p.text[start:p.pos])
+}
+
+func (p *wktParser) number() (float64, error) {
+	p.skipSpace()
+	start := p.pos
+	for p.pos < len(p.text) && strings.IndexByte("+-.0123456789eE", p.text[p.pos]) >= 0 {
+		p.pos++
+	}
+	value, err := strconv.ParseFloat(p.text[start:p.pos], 64)
+	if err != nil {
+		p.pos = start
+		return 0, fmt.Errorf("expected a number at %q", p.rest())
+	}
+	return value, nil
+}
+
+// x y
+func (p *wktParser) position() (geom.Point, error) {
+	x, err := p.number()
+	if err != nil {
+		return geom.Point{}, err
+	}
+	y, err := p.number()
+	if err != nil {
+		return geom.Point{}, err
+	}
+	return geom.Pt(x, y), nil
+}
+
+// POINT (x y), MULTIPOINT ((x y), (x y)) or MULTIPOINT (x y, x y), or either of them EMPTY
+func (p *wktParser) geometry() ([]geom.Point, error) {
+	kind := p.word()
+	if kind != "POINT" && kind != "MULTIPOINT" {
+		if kind == "" {
+			return nil, fmt.Errorf("expected a geometry at %q", p.rest())
+		}
+		return nil, fmt.Errorf("%s geometry, only POINT and MULTIPOINT are supported", kind)
+	}
+	if p.word() == "EMPTY" {
+		return nil, nil
+	}
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+
+	var points []geom.Point
+	for {
+		parenthesized := kind == "MULTIPOINT" && p.accept("(")
+		point, err := p.position()
+		if err != nil {
+			return nil, err
+		}
+		points = append(points, point)
+		if parenthesized {
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+		}
+		if kind == "POINT" || !p.accept(",") {
+			break
+		}
+	}
+	return points, p.expect(")")
+}
+
+// Write the cells as WKT POLYGONs, one line per seed in the same order. Empty cells are
+// POLYGON EMPTY.
+func writeWKTCells(w io.Writer, cells [][]geom.Point) error {
+	out := bufio.NewWriter(w)
+	format := func(x float64) string {
+		return strconv.FormatFloat(x, 'g', -1, 64)
+	}
+	for _, cell := range cells {
+		if len(cell) == 0 {
+			fmt.Fprintln(out, "POLYGON EMPTY")
+			continue
+		}
+		out.WriteString("POLYGON ((")
+		// The ring is closed by repeating the first point
+		for j := 0; j <= len(cell); j++ {
+			if j > 0 {
+				out.WriteString(", ")
+			}
+			point := cell[j%len(cell)]
+			out.WriteString(format(point.X) + " " + format(point.Y))
+		}
+		out.WriteString("))\n")
+	}
+	return out.Flush()
+}
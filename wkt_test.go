@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"voronoi/geom"
+)
+
+func TestReadWKTPoints(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []geom.Point
+	}{
+		{"POINT (1 2)", []geom.Point{geom.Pt(1, 2)}},
+		{"point(1.5 -2e3)\n  POINT ( 3 4 )  ", []geom.Point{geom.Pt(1.5, -2000), geom.Pt(3, 4)}},
+		{"MULTIPOINT ((1 2), (3 4))", []geom.Point{geom.Pt(1, 2), geom.Pt(3, 4)}},
+		{"MULTIPOINT (1 2, 3 4)\nPOINT (5 6)", []geom.Point{geom.Pt(1, 2), geom.Pt(3, 4), geom.Pt(5, 6)}},
+		{"POINT EMPTY\nMULTIPOINT EMPTY\nPOINT (1 2)", []geom.Point{geom.Pt(1, 2)}},
+		{"", nil},
+	}
+	for _, test := range tests {
+		points, err := readWKTPoints(strings.NewReader(test.input))
+		if err != nil || !reflect.DeepEqual(points, test.want) {
+			t.Errorf("readWKTPoints(%q) = %v, %v, want %v", test.input, points, err, test.want)
+		}
+	}
+}
+
+func TestReadWKTPointsErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"POINT (1 2)\nLINESTRING (1 2, 3 4)", "line 2: LINESTRING geometry, only POINT and MULTIPOINT are supported"},
+		{"POINT (1 2)\n\nPOINT (1 x)", `line 3: expected a number at "x)"`},
+		{"POINT (1 2", `line 1: expected ")" at ""`},
+		{"POINT 1 2", `line 1: expected "(" at "1 2"`},
+		{"(1 2)", `line 1: expected a geometry at "(1 2)"`},
+	}
+	for _, test := range tests {
+		_, err := readWKTPoints(strings.NewReader(test.input))
+		if err == nil || err.Error() != test.want {
+			t.Errorf("readWKTPoints(%q): got error %v, want %s", test.input, err, test.want)
+		}
+	}
+}
+
+func TestWriteWKTCells(t *testing.T) {
+	cells := [][]geom.Point{
+		{geom.Pt(0, 0), geom.Pt(0.5, 0), geom.Pt(0.5, 1), geom.Pt(0, 1)},
+		nil,
+		{geom.Pt(0.5, 0), geom.Pt(1, 0), geom.Pt(1, 1e-7)},
+	}
+	var out bytes.Buffer
+	if err := writeWKTCells(&out, cells); err != nil {
+		t.Fatal(err)
+	}
+	want := "POLYGON ((0 0, 0.5 0, 0.5 1, 0 1, 0 0))\n" +
+		"POLYGON EMPTY\n" +
+		"POLYGON ((0.5 0, 1 0, 1 1e-07, 0.5 0))\n"
+	if out.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", out.String(), want)
+	}
+}